@@ -5,28 +5,58 @@ import (
 	"fmt"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Memonagi/wallet_project/internal/application"
 	"github.com/Memonagi/wallet_project/internal/config"
 	"github.com/Memonagi/wallet_project/internal/consumer"
 	"github.com/Memonagi/wallet_project/internal/database"
+	grpcserver "github.com/Memonagi/wallet_project/internal/grpc-server"
+	"github.com/Memonagi/wallet_project/internal/health"
 	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
 	"github.com/Memonagi/wallet_project/internal/producer"
+	"github.com/Memonagi/wallet_project/internal/ratelimit"
+	"github.com/Memonagi/wallet_project/internal/revocation"
 	"github.com/Memonagi/wallet_project/internal/server"
+	"github.com/Memonagi/wallet_project/internal/tracing"
+	"github.com/Memonagi/wallet_project/internal/webhooks"
 	xrclient "github.com/Memonagi/wallet_project/internal/xr/xr-client"
+	xrrates "github.com/Memonagi/wallet_project/internal/xr/xr-rates"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	migrate "github.com/rubenv/sql-migrate"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
-//nolint:funlen
+const tracingShutdownTimeout = 5 * time.Second
+
+//nolint:funlen,cyclop
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
 	defer cancel()
 
 	cfg := config.New()
 
+	tracerProvider, err := tracing.New(ctx, tracing.Config{
+		ServiceName: "wallet-service",
+		Exporter:    cfg.GetTraceExporter(),
+		Endpoint:    cfg.GetOTLPEndpoint(),
+	})
+	if err != nil {
+		logrus.Panicf("failed to set up tracing: %v", err)
+	}
+
+	logrus.AddHook(tracing.LogHook{})
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+			logrus.Warnf("failed to shutdown tracing: %v", err)
+		}
+	}()
+
 	db, err := database.New(ctx, database.Config{Dsn: cfg.GetPostgresDSN()})
 	if err != nil {
 		logrus.Panicf("failed to connect to database: %v", err)
@@ -38,7 +68,10 @@ func main() {
 
 	logrus.Info("migrated successfully")
 
-	kafkaConsumer, err := consumer.New(db, consumer.Config{Port: cfg.GetKafkaPort()})
+	client := xrclient.New(cfg.GetXRClientConfig())
+	rates := xrrates.New(cfg.GetXRRatesConfig(), client)
+
+	kafkaConsumer, err := consumer.New(db, rates, consumer.Config{Port: cfg.GetKafkaPort()})
 	if err != nil {
 		logrus.Panicf("failed to connect to consumer: %v", err)
 	}
@@ -60,10 +93,39 @@ func main() {
 		}
 	}()
 
-	client := xrclient.New(xrclient.Config{ServerAddress: cfg.GetXRServerAddress()})
-	svc := application.New(db, client, txProducer)
-	jwtClaims := jwtclaims.New()
-	httpServer := server.New(server.Config{Port: cfg.GetAppPort()}, svc, jwtClaims.GetPublicKey())
+	svc := application.New(cfg.GetServiceConfig(), db, rates, txProducer)
+
+	verifier, err := jwtclaims.NewVerifier(ctx, cfg.GetVerifierConfig())
+	if err != nil {
+		logrus.Panicf("failed to load token keys: %v", err)
+	}
+
+	revocations := revocation.New(db, cfg.GetRevocationConfig())
+
+	var limiter *ratelimit.Limiter
+	if cfg.GetRateLimitShared() {
+		limiter = ratelimit.New(db)
+	} else {
+		limiter = ratelimit.New(ratelimit.NewMemory())
+	}
+
+	healthChecks := health.New()
+	healthChecks.Add("postgres", db.Ping)
+	healthChecks.Add("migrations", db.CheckMigrations)
+	healthChecks.Add("kafka", kafkaConsumer.CheckBroker)
+	healthChecks.Add("consumer", kafkaConsumer.CheckRunning)
+	healthChecks.Add("xr", client.Check)
+
+	httpServer := server.New(server.Config{
+		Port:       cfg.GetAppPort(),
+		RateLimits: cfg.GetRateLimits(),
+		DrainDelay: cfg.GetDrainDelay(),
+	}, svc, verifier, revocations, limiter, healthChecks)
+	grpcServer := grpcserver.New(grpcserver.Config{
+		Port:       cfg.GetGRPCPort(),
+		RateLimits: cfg.GetRateLimits(),
+	}, svc, verifier, revocations, limiter)
+	webhookSender := webhooks.New(db, webhooks.Config{})
 
 	eg, ctx := errgroup.WithContext(ctx)
 
@@ -79,12 +141,42 @@ func main() {
 		return fmt.Errorf("server stopped: %w", err)
 	})
 
+	eg.Go(func() error {
+		err := limiter.Run(ctx)
+
+		return fmt.Errorf("rate limits cleanup stopped: %w", err)
+	})
+
+	eg.Go(func() error {
+		err := verifier.Run(ctx)
+
+		return fmt.Errorf("jwks refresh stopped: %w", err)
+	})
+
+	eg.Go(func() error {
+		err := revocations.Run(ctx)
+
+		return fmt.Errorf("token revocations refresh stopped: %w", err)
+	})
+
+	eg.Go(func() error {
+		err := grpcServer.Run(ctx)
+
+		return fmt.Errorf("grpc server stopped: %w", err)
+	})
+
 	eg.Go(func() error {
 		err := svc.Run(ctx)
 
 		return fmt.Errorf("inactive wallets cleanup stopped: %w", err)
 	})
 
+	eg.Go(func() error {
+		err := webhookSender.Run(ctx)
+
+		return fmt.Errorf("webhook sender stopped: %w", err)
+	})
+
 	if err = eg.Wait(); err != nil {
 		logrus.Panicf("eg.Wait(): %v", err)
 	}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type verified struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+	Scope  string `json:"scope"`
+	Issuer string `json:"iss"`
+}
+
+func mintAndVerify(t *testing.T, mintArgs, verifyArgs []string) verified {
+	t.Helper()
+
+	token := &bytes.Buffer{}
+	require.NoError(t, mint(token, mintArgs))
+
+	claims := &bytes.Buffer{}
+	require.NoError(t, verify(claims, append(verifyArgs, strings.TrimSpace(token.String()))))
+
+	result := verified{}
+	require.NoError(t, json.Unmarshal(claims.Bytes(), &result))
+
+	return result
+}
+
+func TestRoundTrip(t *testing.T) {
+	userID := uuid.NewString()
+
+	claims := mintAndVerify(t, []string{
+		"-user", userID, "-email", "pupa@lupa.com", "-scope", "wallets:read webhooks", "-issuer", "wallet-token",
+	}, nil)
+
+	require.Equal(t, userID, claims.UserID)
+	require.Equal(t, "pupa@lupa.com", claims.Email)
+	require.Equal(t, "wallets:read webhooks", claims.Scope)
+	require.Equal(t, "wallet-token", claims.Issuer)
+}
+
+func TestRoundTripES256(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+
+	err = os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDER}), 0o600)
+	require.NoError(t, err)
+
+	err = os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600)
+	require.NoError(t, err)
+
+	userID := uuid.NewString()
+
+	claims := mintAndVerify(t, []string{"-user", userID, "-key", privatePath}, []string{"-key", publicPath})
+	require.Equal(t, userID, claims.UserID)
+
+	t.Run("wrong key", func(t *testing.T) {
+		token := &bytes.Buffer{}
+		require.NoError(t, mint(token, []string{"-key", privatePath}))
+
+		err := verify(&bytes.Buffer{}, []string{strings.TrimSpace(token.String())})
+		require.Error(t, err)
+	})
+}
+
+func TestVerifyUsage(t *testing.T) {
+	require.ErrorIs(t, verify(&bytes.Buffer{}, nil), errUsage)
+	require.ErrorIs(t, mint(&bytes.Buffer{}, []string{"-unknown"}), errUsage)
+}
@@ -4,22 +4,141 @@ import (
 	"context"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/Memonagi/wallet_project/internal/config"
+	"github.com/Memonagi/wallet_project/internal/database"
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/producer"
+	"github.com/Memonagi/wallet_project/internal/revocation"
+	"github.com/Memonagi/wallet_project/internal/tracing"
 	xrserver "github.com/Memonagi/wallet_project/internal/xr/xr-server"
 	xrservice "github.com/Memonagi/wallet_project/internal/xr/xr-service"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 )
 
-const port = 2607
+const tracingShutdownTimeout = 5 * time.Second
 
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM)
 	defer cancel()
 
-	svc := xrservice.New()
-	server := xrserver.New(port, svc)
+	cfg := config.New()
 
-	if err := server.Run(ctx); err != nil {
+	tracerProvider, err := tracing.New(ctx, tracing.Config{
+		ServiceName: "xr-service",
+		Exporter:    cfg.GetTraceExporter(),
+		Endpoint:    cfg.GetOTLPEndpoint(),
+	})
+	if err != nil {
+		logrus.Panicf("failed to set up tracing: %v", err)
+	}
+
+	logrus.AddHook(tracing.LogHook{})
+
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+
+		if err := tracerProvider.Shutdown(shutdownCtx); err != nil {
+			logrus.Warnf("failed to shutdown tracing: %v", err)
+		}
+	}()
+
+	serviceConfig := cfg.GetXRServiceConfig()
+
+	if path := cfg.GetXRQuotingFile(); path != "" {
+		if serviceConfig.Quoting, err = xrservice.LoadQuoting(path); err != nil {
+			logrus.Panicf("failed to load quoting: %v", err)
+		}
+	}
+
+	provider, fallback := rateProviders(cfg)
+	serviceConfig.Fallback = fallback
+
+	svc, err := xrservice.New(ctx, serviceConfig, provider)
+	if err != nil {
+		logrus.Panicf("failed to load rates: %v", err)
+	}
+
+	verifier, err := jwtclaims.NewVerifier(ctx, cfg.GetVerifierConfig())
+	if err != nil {
+		logrus.Panicf("failed to load token keys: %v", err)
+	}
+
+	// the token revocations of the wallet service, which owns and migrates the database
+	db, err := database.New(ctx, database.Config{Dsn: cfg.GetPostgresDSN()})
+	if err != nil {
+		logrus.Panicf("failed to connect to database: %v", err)
+	}
+
+	revocations := revocation.New(db, cfg.GetRevocationConfig())
+
+	ratesProducer, err := producer.New(producer.Config{Address: cfg.GetKafkaPort()})
+	if err != nil {
+		logrus.Panicf("failed to create producer: %v", err)
+	}
+
+	defer func() {
+		if err = ratesProducer.Close(); err != nil {
+			logrus.Warnf("failed to close producer: %v", err)
+		}
+	}()
+
+	publisher := xrservice.NewPublisher(cfg.GetXRPublisherConfig(), svc, ratesProducer)
+	server := xrserver.New(cfg.GetXRServerConfig(), svc, verifier, revocations)
+
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		return verifier.Run(ctx)
+	})
+
+	eg.Go(func() error {
+		return revocations.Run(ctx)
+	})
+
+	eg.Go(func() error {
+		return svc.Run(ctx)
+	})
+
+	eg.Go(func() error {
+		return publisher.Run(ctx)
+	})
+
+	eg.Go(func() error {
+		return server.Run(ctx)
+	})
+
+	if err = eg.Wait(); err != nil {
 		logrus.Panicf("failed to start server: %v", err)
 	}
 }
+
+// rateProviders returns the configured sources, the ECB feed and then the rates file, chained so that every
+// refresh falls back to the rates file when the feed fails. The built-in rates are used only when no source
+// is configured, or as the startup fallback, so that a failed refresh never replaces real rates with made up ones.
+func rateProviders(cfg *config.Config) (xrservice.Provider, xrservice.Provider) {
+	var providers []xrservice.Provider
+
+	if source := cfg.GetXRECBSource(); source != "" {
+		providers = append(providers, xrservice.NewECB(source))
+	}
+
+	if path := cfg.GetXRRatesFile(); path != "" {
+		providers = append(providers, xrservice.NewStaticFile(path))
+	}
+
+	builtin := xrservice.NewStatic(xrservice.DefaultRates())
+
+	if len(providers) == 0 {
+		return builtin, nil
+	}
+
+	if len(providers) == 1 {
+		return providers[0], builtin
+	}
+
+	return xrservice.NewChain(providers...), builtin
+}
@@ -0,0 +1,102 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+const (
+	apiKeyPrefix    = "wk_"
+	apiKeyLen       = 32
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+func (s *Service) CreateAPIKey(ctx context.Context, userInfo models.UserInfo,
+	apiKey models.APIKey,
+) (models.APIKey, error) {
+	if userInfo.Role != models.RoleAdmin {
+		return models.APIKey{}, models.ErrAdminRequired
+	}
+
+	if err := apiKey.Validate(); err != nil {
+		return models.APIKey{}, fmt.Errorf("%w", err)
+	}
+
+	secret := make([]byte, apiKeyLen)
+	if _, err := rand.Read(secret); err != nil {
+		return models.APIKey{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	apiKey.Prefix = key[:apiKeyPrefixLen]
+
+	newAPIKey, err := s.wallets.CreateAPIKey(ctx, apiKey, hashAPIKey(key))
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("failed create api key: %w", err)
+	}
+
+	newAPIKey.Key = key
+
+	return newAPIKey, nil
+}
+
+func (s *Service) GetAPIKeys(ctx context.Context, userInfo models.UserInfo) ([]models.APIKey, error) {
+	if userInfo.Role != models.RoleAdmin {
+		return nil, models.ErrAdminRequired
+	}
+
+	apiKeys, err := s.wallets.GetAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed get api keys: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+func (s *Service) DeleteAPIKey(ctx context.Context, userInfo models.UserInfo, apiKeyID models.APIKeyID) error {
+	if userInfo.Role != models.RoleAdmin {
+		return models.ErrAdminRequired
+	}
+
+	if err := s.wallets.DeleteAPIKey(ctx, apiKeyID); err != nil {
+		return fmt.Errorf("failed delete api key: %w", err)
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey resolves an API key to the caller it acts as.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, key string) (models.UserInfo, error) {
+	apiKey, err := s.wallets.GetAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, models.ErrAPIKeyNotFound) {
+			return models.UserInfo{}, models.ErrInvalidToken
+		}
+
+		return models.UserInfo{}, fmt.Errorf("failed authenticate api key: %w", err)
+	}
+
+	userInfo := models.UserInfo{
+		Scopes:   apiKey.Scopes,
+		APIKeyID: &apiKey.ID,
+	}
+
+	if apiKey.UserID != nil {
+		userInfo.UserID = *apiKey.UserID
+	}
+
+	return userInfo, nil
+}
+
+// hashAPIKey is a plain digest: keys are random, so they need no salt or stretching.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -3,11 +3,14 @@ package application
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Memonagi/wallet_project/internal/models"
 	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
 )
 
 //nolint:interfacebloat
@@ -19,43 +22,134 @@ type wallets interface {
 		rate float64) (models.Wallet, error)
 	DeleteWallet(ctx context.Context, walletID models.WalletID, userID models.UserID) error
 	GetWallets(ctx context.Context, request models.GetWalletsRequest, userID models.UserID) ([]models.Wallet, error)
+	GetAllWallets(ctx context.Context, userID models.UserID) ([]models.Wallet, error)
 	GetCurrency(ctx context.Context, walletID models.WalletID) (models.WalletUpdate, error)
-	Deposit(ctx context.Context, userID models.UserID, transaction models.Transaction) error
+	Deposit(ctx context.Context, userID *models.UserID, transaction models.Transaction) error
 	WithdrawMoney(ctx context.Context, userID models.UserID, transaction models.Transaction) error
 	Transfer(ctx context.Context, userID models.UserID, transaction models.Transaction, rate float64) error
 	GetTransactions(ctx context.Context, request models.GetWalletsRequest,
 		walletID models.WalletID) ([]models.Transaction, error)
+	GetTransaction(ctx context.Context, txID models.TxID, walletID models.WalletID) (models.Transaction, error)
 	WalletCleaner(ctx context.Context) error
+	GetUser(ctx context.Context, userID models.UserID) (models.User, error)
+	GetWalletsSummary(ctx context.Context, userID models.UserID) (models.WalletsSummary, error)
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, userID *models.UserID) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID models.WebhookID, userID *models.UserID) error
+	GetWebhookDeliveries(ctx context.Context, request models.GetWalletsRequest, webhookID models.WebhookID,
+		userID *models.UserID) ([]models.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, deliveryID models.DeliveryID, webhookID models.WebhookID,
+		userID *models.UserID) error
+	GetWalletEvents(ctx context.Context, userID models.UserID, afterSeq int64,
+		limit int) ([]models.WalletEvent, error)
+	GetLastWalletEventSeq(ctx context.Context, userID models.UserID) (int64, error)
+	DeleteWalletEvents(ctx context.Context, before time.Time) error
+	ListenWalletEvents(ctx context.Context, notify func(userID models.UserID)) error
+	CreateAPIKey(ctx context.Context, apiKey models.APIKey, keyHash string) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, apiKeyID models.APIKeyID) error
+	CreateTOTP(ctx context.Context, userID models.UserID, secret string) error
+	GetTOTP(ctx context.Context, userID models.UserID) (models.TOTP, error)
+	AcceptTOTPStep(ctx context.Context, userID models.UserID, step int64) error
+	FailTOTPAttempt(ctx context.Context, userID models.UserID, maxAttempts int, lockedUntil time.Time) error
+	CreatePendingOperation(ctx context.Context, operation models.PendingOperation) (models.PendingOperation, error)
+	GetPendingOperation(ctx context.Context, operationID models.OperationID,
+		userID models.UserID) (models.PendingOperation, error)
+	ConfirmPendingOperation(ctx context.Context, operationID models.OperationID) error
+	DeletePendingOperations(ctx context.Context, before time.Time) error
+	CreateQuote(ctx context.Context, quote models.Quote) (models.Quote, error)
+	GetQuote(ctx context.Context, quoteID models.QuoteID, userID models.UserID) (models.Quote, error)
+	ExtendQuote(ctx context.Context, quoteID models.QuoteID, userID models.UserID, expiresAt time.Time) error
+	DeleteQuotes(ctx context.Context, before time.Time) error
 }
 
 type xrClient interface {
 	GetRate(ctx context.Context, from, to string) (float64, error)
+	GetQuote(ctx context.Context, from, to string) (models.XRResponse, error)
+	GetQuoteAt(ctx context.Context, from, to string, at time.Time) (models.XRResponse, error)
+	GetRatesTo(ctx context.Context, to string) (models.RatesTo, error)
 }
 
 //go:generate mockgen -source=service.go -destination=../mocks/mock_txproducer.gen.go -package=mocks txProducer
 type txProducer interface {
-	ProduceTx(key, value string) error
+	ProduceTx(ctx context.Context, key, value string) error
+	ProduceWallet(ctx context.Context, key, value string) error
 }
 
 type Service struct {
-	wallets  wallets
-	xrClient xrClient
-	producer txProducer
-	metrics  *metrics
+	wallets       wallets
+	xrClient      xrClient
+	producer      txProducer
+	metrics       *metrics
+	hub           *hub
+	stepUp        StepUp
+	quoteTTL      time.Duration
+	conversionFee float64
+}
+
+type Config struct {
+	StepUp StepUp
+	// QuoteTTL is how long a quoted rate can be used for a transfer.
+	QuoteTTL time.Duration
+	// ConversionFee is the part of a converted deposit or withdrawal charged as a fee, e.g. 0.01 for 1%.
+	ConversionFee float64
 }
 
 const cleanupTicker = 24 * time.Hour
 
-func New(wallets wallets, xrClient xrClient, producer txProducer) *Service {
+func New(cfg Config, wallets wallets, xrClient xrClient, producer txProducer) *Service {
+	if cfg.StepUp.Currency == "" {
+		cfg.StepUp.Currency = defaultStepUpCurrency
+	}
+
+	if cfg.StepUp.TTL <= 0 {
+		cfg.StepUp.TTL = defaultOperationTTL
+	}
+
+	if cfg.StepUp.MaxAttempts <= 0 {
+		cfg.StepUp.MaxAttempts = defaultMaxAttempts
+	}
+
+	if cfg.StepUp.Lockout <= 0 {
+		cfg.StepUp.Lockout = defaultLockout
+	}
+
+	if cfg.QuoteTTL <= 0 {
+		cfg.QuoteTTL = defaultQuoteTTL
+	}
+
 	return &Service{
-		wallets:  wallets,
-		xrClient: xrClient,
-		producer: producer,
-		metrics:  newMetrics(),
+		wallets:       wallets,
+		xrClient:      xrClient,
+		producer:      producer,
+		metrics:       newMetrics(),
+		hub:           newHub(),
+		stepUp:        cfg.StepUp,
+		quoteTTL:      cfg.QuoteTTL,
+		conversionFee: cfg.ConversionFee,
 	}
 }
 
 func (s *Service) Run(ctx context.Context) error {
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		return s.runHub(ctx)
+	})
+
+	eg.Go(func() error {
+		return s.runCleanup(ctx)
+	})
+
+	if err := eg.Wait(); err != nil {
+		return fmt.Errorf("service stopped: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) runCleanup(ctx context.Context) error {
 	t := time.NewTicker(cleanupTicker)
 	defer t.Stop()
 
@@ -67,6 +161,18 @@ func (s *Service) Run(ctx context.Context) error {
 			if err := s.cleanupWallet(ctx); err != nil {
 				return fmt.Errorf("failed to cleanup inactive wallets: %w", err)
 			}
+
+			if err := s.wallets.DeleteWalletEvents(ctx, time.Now().Add(-eventsRetention)); err != nil {
+				return fmt.Errorf("failed to cleanup wallet events: %w", err)
+			}
+
+			if err := s.wallets.DeletePendingOperations(ctx, time.Now()); err != nil {
+				return fmt.Errorf("failed to cleanup pending operations: %w", err)
+			}
+
+			if err := s.wallets.DeleteQuotes(ctx, time.Now()); err != nil {
+				return fmt.Errorf("failed to cleanup quotes: %w", err)
+			}
 		}
 	}
 }
@@ -149,10 +255,14 @@ func (s *Service) UpdateWallet(ctx context.Context, walletID models.WalletID, us
 	}
 
 	if baseWallet.Currency != wallet.Currency {
-		rate, err = s.xrClient.GetRate(ctx, *baseWallet.Currency, *wallet.Currency)
-		if err != nil {
+		var quote models.XRResponse
+
+		// the balance is sold in its currency, at the bid
+		if quote, err = s.xrClient.GetQuote(ctx, *baseWallet.Currency, *wallet.Currency); err != nil {
 			return models.Wallet{}, fmt.Errorf("failed get rate: %w", err)
 		}
+
+		rate = quote.Bid
 	}
 
 	baseWallet.Currency = wallet.Currency
@@ -174,6 +284,20 @@ func (s *Service) DeleteWallet(ctx context.Context, walletID models.WalletID, us
 		return fmt.Errorf("failed delete wallet: %w", err)
 	}
 
+	walletJSON, err := json.Marshal(models.Wallet{
+		WalletID:  walletID,
+		UserID:    userID,
+		Archived:  true,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal archived wallet: %w", err)
+	}
+
+	if err = s.producer.ProduceWallet(ctx, "", string(walletJSON)); err != nil {
+		return fmt.Errorf("failed to produce archived wallet: %w", err)
+	}
+
 	return nil
 }
 
@@ -189,7 +313,7 @@ func (s *Service) GetWallets(ctx context.Context, request models.GetWalletsReque
 }
 
 //nolint:dupl
-func (s *Service) Deposit(ctx context.Context, userID models.UserID, transaction models.Transaction) error {
+func (s *Service) Deposit(ctx context.Context, userInfo models.UserInfo, transaction models.Transaction) error {
 	var err error
 	defer func() {
 		if err != nil {
@@ -203,7 +327,13 @@ func (s *Service) Deposit(ctx context.Context, userID models.UserID, transaction
 		return fmt.Errorf("error validating transaction: %w", err)
 	}
 
-	if err = s.wallets.Deposit(ctx, userID, transaction); err != nil {
+	transaction.Name = models.EventDeposit
+
+	if err = s.convert(ctx, &transaction); err != nil {
+		return err
+	}
+
+	if err = s.wallets.Deposit(ctx, depositOwner(userInfo), transaction); err != nil {
 		return fmt.Errorf("failed deposit: %w", err)
 	}
 
@@ -212,20 +342,31 @@ func (s *Service) Deposit(ctx context.Context, userID models.UserID, transaction
 		return fmt.Errorf("failed to marshal deposit transaction: %w", err)
 	}
 
-	if err = s.producer.ProduceTx("", string(txJSON)); err != nil {
+	if err = s.producer.ProduceTx(ctx, "", string(txJSON)); err != nil {
 		return fmt.Errorf("failed to produce deposit transaction: %w", err)
 	}
 
 	return nil
 }
 
-//nolint:dupl
+// WithdrawMoney withdraws the money, or stores the withdrawal and returns a ConfirmationRequiredError
+// if it is above the step-up threshold.
 func (s *Service) WithdrawMoney(ctx context.Context, userID models.UserID, transaction models.Transaction) error {
+	return s.withdraw(ctx, userID, transaction, false)
+}
+
+//nolint:dupl
+func (s *Service) withdraw(ctx context.Context, userID models.UserID, transaction models.Transaction,
+	confirmed bool,
+) error {
 	var err error
 	defer func() {
-		if err != nil {
+		switch {
+		case errors.Is(err, models.ErrConfirmationRequired):
+			s.metrics.txPending.WithLabelValues("withdraw").Inc()
+		case err != nil:
 			s.metrics.txFailed.WithLabelValues("withdraw").Inc()
-		} else {
+		default:
 			s.metrics.txCompleted.WithLabelValues("withdraw").Inc()
 		}
 	}()
@@ -234,6 +375,18 @@ func (s *Service) WithdrawMoney(ctx context.Context, userID models.UserID, trans
 		return fmt.Errorf("error validating transaction: %w", err)
 	}
 
+	if !confirmed {
+		if err = s.requireConfirmation(ctx, userID, models.EventWithdraw, transaction); err != nil {
+			return err
+		}
+	}
+
+	transaction.Name = models.EventWithdraw
+
+	if err = s.convert(ctx, &transaction); err != nil {
+		return err
+	}
+
 	if err = s.wallets.WithdrawMoney(ctx, userID, transaction); err != nil {
 		return fmt.Errorf("failed withdraw money: %w", err)
 	}
@@ -243,19 +396,31 @@ func (s *Service) WithdrawMoney(ctx context.Context, userID models.UserID, trans
 		return fmt.Errorf("failed to marshal withdraw transaction: %w", err)
 	}
 
-	if err = s.producer.ProduceTx("", string(txJSON)); err != nil {
+	if err = s.producer.ProduceTx(ctx, "", string(txJSON)); err != nil {
 		return fmt.Errorf("failed to produce withdraw transaction: %w", err)
 	}
 
 	return nil
 }
 
+// Transfer transfers the money, or stores the transfer and returns a ConfirmationRequiredError
+// if it is above the step-up threshold. A transfer with a quote is converted at the quoted rate,
+// which has to be still valid when the transfer is confirmed.
 func (s *Service) Transfer(ctx context.Context, userID models.UserID, transaction models.Transaction) error {
+	return s.transfer(ctx, userID, transaction, false)
+}
+
+func (s *Service) transfer(ctx context.Context, userID models.UserID, transaction models.Transaction,
+	confirmed bool,
+) error {
 	var err error
 	defer func() {
-		if err != nil {
+		switch {
+		case errors.Is(err, models.ErrConfirmationRequired):
+			s.metrics.txPending.WithLabelValues("transfer").Inc()
+		case err != nil:
 			s.metrics.txFailed.WithLabelValues("transfer").Inc()
-		} else {
+		default:
 			s.metrics.txCompleted.WithLabelValues("transfer").Inc()
 		}
 	}()
@@ -275,13 +440,33 @@ func (s *Service) Transfer(ctx context.Context, userID models.UserID, transactio
 
 	rate := 1.00
 
-	if secondWallet.Currency != &transaction.Currency {
-		rate, err = s.xrClient.GetRate(ctx, transaction.Currency, *secondWallet.Currency)
-		if err != nil {
+	switch {
+	case transaction.QuoteID != nil:
+		if rate, err = s.quotedRate(ctx, userID, transaction, *secondWallet.Currency); err != nil {
+			return err
+		}
+	case !strings.EqualFold(*secondWallet.Currency, transaction.Currency):
+		var quote models.XRResponse
+
+		// the sender sells the amount in their currency, at the bid
+		if quote, err = s.xrClient.GetQuote(ctx, transaction.Currency, *secondWallet.Currency); err != nil {
 			return fmt.Errorf("failed get rate: %w", err)
 		}
+
+		rate = quote.Bid
 	}
 
+	// the quote is checked before the confirmation, which keeps it until the confirmation deadline
+	if !confirmed {
+		if err = s.requireConfirmation(ctx, userID, models.EventTransfer, transaction); err != nil {
+			return err
+		}
+	}
+
+	transaction.Name = models.EventTransfer
+	transaction.Rate = &rate
+	transaction.Conversion = nil
+
 	if err = s.wallets.Transfer(ctx, userID, transaction, rate); err != nil {
 		return fmt.Errorf("failed transfer transaction: %w", err)
 	}
@@ -291,7 +476,7 @@ func (s *Service) Transfer(ctx context.Context, userID models.UserID, transactio
 		return fmt.Errorf("failed to marshal transfer transaction: %w", err)
 	}
 
-	if err = s.producer.ProduceTx("", string(txJSON)); err != nil {
+	if err = s.producer.ProduceTx(ctx, "", string(txJSON)); err != nil {
 		return fmt.Errorf("failed to produce transfer transaction: %w", err)
 	}
 
@@ -303,7 +488,7 @@ func (s *Service) GetTransactions(ctx context.Context, request models.GetWallets
 ) ([]models.Transaction, error) {
 	_, err := s.GetWallet(ctx, walletID, userID)
 	if err != nil {
-		return nil, fmt.Errorf("%w", models.ErrWrongUserID)
+		return nil, fmt.Errorf("%w", err)
 	}
 
 	transactions, err := s.wallets.GetTransactions(ctx, request, walletID)
@@ -313,3 +498,97 @@ func (s *Service) GetTransactions(ctx context.Context, request models.GetWallets
 
 	return transactions, nil
 }
+
+// GetTransactionRate returns the rate that applied to a transaction when it was made.
+// Transfers between wallets of different currencies are converted, and deposits and withdrawals
+// that asked to be, at the rate recorded with them. A transfer's rate is the one it was applied at,
+// the quoted one when the transfer had a quote.
+func (s *Service) GetTransactionRate(ctx context.Context, userID models.UserID, walletID models.WalletID,
+	txID models.TxID,
+) (models.TransactionRate, error) {
+	if _, err := s.GetWallet(ctx, walletID, userID); err != nil {
+		return models.TransactionRate{}, fmt.Errorf("%w", err)
+	}
+
+	transaction, err := s.wallets.GetTransaction(ctx, txID, walletID)
+	if err != nil {
+		return models.TransactionRate{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	txRate := models.TransactionRate{
+		TransactionID: transaction.ID,
+		FromCurrency:  transaction.Currency,
+		ToCurrency:    transaction.Currency,
+		Rate:          1,
+		At:            transaction.CreatedAt,
+	}
+
+	if transaction.Conversion != nil {
+		txRate.ToCurrency = transaction.Conversion.WalletCurrency
+		txRate.Rate = transaction.Conversion.Rate
+
+		return txRate, nil
+	}
+
+	if transaction.Name != models.EventTransfer || transaction.SecondWalletID == nil {
+		return txRate, nil
+	}
+
+	secondWallet, err := s.wallets.GetCurrency(ctx, *transaction.SecondWalletID)
+	if err != nil {
+		return models.TransactionRate{}, fmt.Errorf("failed to get second wallet: %w", err)
+	}
+
+	if secondWallet.Currency == nil || *secondWallet.Currency == transaction.Currency {
+		return txRate, nil
+	}
+
+	txRate.ToCurrency = *secondWallet.Currency
+
+	if transaction.Rate != nil {
+		txRate.Rate = *transaction.Rate
+
+		return txRate, nil
+	}
+
+	// transfers made before their rate was stored are looked up at the rate of their time
+	quote, err := s.xrClient.GetQuoteAt(ctx, txRate.FromCurrency, txRate.ToCurrency, transaction.CreatedAt)
+	if err != nil {
+		return models.TransactionRate{}, fmt.Errorf("failed to get rate: %w", err)
+	}
+
+	txRate.Rate = quote.Bid
+
+	return txRate, nil
+}
+
+func (s *Service) GetProfile(ctx context.Context, userID models.UserID) (models.Profile, error) {
+	if userID == models.UserID(uuid.Nil) {
+		return models.Profile{}, fmt.Errorf("%w", models.ErrUserID)
+	}
+
+	user, err := s.wallets.GetUser(ctx, userID)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	summary, err := s.wallets.GetWalletsSummary(ctx, userID)
+	if err != nil {
+		return models.Profile{}, fmt.Errorf("failed to get wallets summary: %w", err)
+	}
+
+	return models.Profile{
+		User:    user,
+		Wallets: summary,
+	}, nil
+}
+
+// depositOwner limits deposits to the caller's wallets unless the caller is a service key,
+// which may deposit to any wallet.
+func depositOwner(userInfo models.UserInfo) *models.UserID {
+	if userInfo.IsService() {
+		return nil
+	}
+
+	return &userInfo.UserID
+}
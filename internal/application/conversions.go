@@ -0,0 +1,57 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+// convert sets how a deposit or withdrawal that asks to be converted changes the balance of the wallet.
+// The wallet sells the pair at the bid on a deposit, and buys it at the ask on a withdrawal.
+// The fee is charged in the wallet currency. A transaction in the currency of the wallet isn't converted.
+func (s *Service) convert(ctx context.Context, transaction *models.Transaction) error {
+	transaction.Conversion = nil
+
+	if !transaction.Convert {
+		return nil
+	}
+
+	wallet, err := s.wallets.GetCurrency(ctx, transaction.FirstWalletID)
+	if err != nil {
+		return fmt.Errorf("wallet not found: %w", err)
+	}
+
+	if wallet.Currency == nil || strings.EqualFold(*wallet.Currency, transaction.Currency) {
+		return nil
+	}
+
+	quote, err := s.xrClient.GetQuote(ctx, transaction.Currency, *wallet.Currency)
+	if err != nil {
+		return fmt.Errorf("failed get rate: %w", err)
+	}
+
+	conversion := models.TxConversion{
+		WalletCurrency: *wallet.Currency,
+		Rate:           quote.Bid,
+		MidRate:        quote.Rate,
+	}
+
+	if transaction.Name == models.EventWithdraw {
+		conversion.Rate = quote.Ask
+	}
+
+	money := transaction.Money * conversion.Rate
+	conversion.Fee = money * s.conversionFee
+
+	if transaction.Name == models.EventWithdraw {
+		conversion.WalletMoney = money + conversion.Fee
+	} else {
+		conversion.WalletMoney = money - conversion.Fee
+	}
+
+	transaction.Conversion = &conversion
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 type metrics struct {
 	txFailed    *prometheus.CounterVec
 	txCompleted *prometheus.CounterVec
+	txPending   *prometheus.CounterVec
 }
 
 const (
@@ -33,6 +34,14 @@ func newMetrics() *metrics {
 				Help:      "Number of completed transactions.",
 			},
 			[]string{"endpoint"}),
+		txPending: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "tx_confirmation_required_total",
+				Help:      "Number of transactions held for step-up confirmation.",
+			},
+			[]string{"endpoint"}),
 	}
 
 	return &metricList
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Memonagi/wallet_project/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -205,6 +206,51 @@ func (mr *MockxrClientMockRecorder) GetRate(ctx, from, to interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRate", reflect.TypeOf((*MockxrClient)(nil).GetRate), ctx, from, to)
 }
 
+// GetQuote mocks base method.
+func (m *MockxrClient) GetQuote(ctx context.Context, from, to string) (models.XRResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuote", ctx, from, to)
+	ret0, _ := ret[0].(models.XRResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuote indicates an expected call of GetQuote.
+func (mr *MockxrClientMockRecorder) GetQuote(ctx, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuote", reflect.TypeOf((*MockxrClient)(nil).GetQuote), ctx, from, to)
+}
+
+// GetQuoteAt mocks base method.
+func (m *MockxrClient) GetQuoteAt(ctx context.Context, from, to string, at time.Time) (models.XRResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuoteAt", ctx, from, to, at)
+	ret0, _ := ret[0].(models.XRResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuoteAt indicates an expected call of GetQuoteAt.
+func (mr *MockxrClientMockRecorder) GetQuoteAt(ctx, from, to, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuoteAt", reflect.TypeOf((*MockxrClient)(nil).GetQuoteAt), ctx, from, to, at)
+}
+
+// GetRatesTo mocks base method.
+func (m *MockxrClient) GetRatesTo(ctx context.Context, to string) (models.RatesTo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRatesTo", ctx, to)
+	ret0, _ := ret[0].(models.RatesTo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRatesTo indicates an expected call of GetRatesTo.
+func (mr *MockxrClientMockRecorder) GetRatesTo(ctx, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRatesTo", reflect.TypeOf((*MockxrClient)(nil).GetRatesTo), ctx, to)
+}
+
 // MocktxProducer is a mock of txProducer interface.
 type MocktxProducer struct {
 	ctrl     *gomock.Controller
@@ -229,15 +275,29 @@ func (m *MocktxProducer) EXPECT() *MocktxProducerMockRecorder {
 }
 
 // ProduceTx mocks base method.
-func (m *MocktxProducer) ProduceTx(key, value string) error {
+func (m *MocktxProducer) ProduceTx(ctx context.Context, key, value string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ProduceTx", key, value)
+	ret := m.ctrl.Call(m, "ProduceTx", ctx, key, value)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ProduceTx indicates an expected call of ProduceTx.
-func (mr *MocktxProducerMockRecorder) ProduceTx(key, value interface{}) *gomock.Call {
+func (mr *MocktxProducerMockRecorder) ProduceTx(ctx, key, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceTx", reflect.TypeOf((*MocktxProducer)(nil).ProduceTx), ctx, key, value)
+}
+
+// ProduceWallet mocks base method.
+func (m *MocktxProducer) ProduceWallet(ctx context.Context, key, value string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceWallet", ctx, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProduceWallet indicates an expected call of ProduceWallet.
+func (mr *MocktxProducerMockRecorder) ProduceWallet(ctx, key, value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceTx", reflect.TypeOf((*MocktxProducer)(nil).ProduceTx), key, value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceWallet", reflect.TypeOf((*MocktxProducer)(nil).ProduceWallet), ctx, key, value)
 }
@@ -0,0 +1,90 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+// GetNetWorth returns the balances of all wallets of the user and their total in the requested currency.
+// All currencies are converted with the rates of one lookup of the rates into the requested currency.
+func (s *Service) GetNetWorth(ctx context.Context, userID models.UserID,
+	request models.NetWorthRequest,
+) (models.NetWorth, error) {
+	if userID == models.UserID(uuid.Nil) {
+		return models.NetWorth{}, fmt.Errorf("%w", models.ErrUserID)
+	}
+
+	if err := request.Validate(); err != nil {
+		return models.NetWorth{}, fmt.Errorf("error validating net worth request: %w", err)
+	}
+
+	netWorth := models.NetWorth{
+		Currency: strings.ToUpper(request.Currency),
+		Wallets:  []models.WalletNetWorth{},
+		Rates:    []models.NetWorthRate{},
+	}
+
+	wallets, err := s.wallets.GetAllWallets(ctx, userID)
+	if err != nil {
+		return models.NetWorth{}, fmt.Errorf("failed get all wallets: %w", err)
+	}
+
+	var rates models.RatesTo
+
+	if len(wallets) > 0 {
+		if rates, err = s.xrClient.GetRatesTo(ctx, netWorth.Currency); err != nil {
+			return models.NetWorth{}, fmt.Errorf("failed get rates: %w", err)
+		}
+	}
+
+	used := map[string]bool{netWorth.Currency: true}
+
+	for _, wallet := range wallets {
+		currency := strings.ToUpper(wallet.Currency)
+
+		rate := 1.0
+		if currency != netWorth.Currency {
+			var ok bool
+
+			if rate, ok = rates.Rates[currency]; !ok {
+				return models.NetWorth{}, fmt.Errorf("failed get rate of %s: %w", currency, models.ErrWrongCurrency)
+			}
+		}
+
+		if !used[currency] {
+			used[currency] = true
+
+			netWorth.Rates = append(netWorth.Rates, models.NetWorthRate{
+				FromCurrency: currency,
+				ToCurrency:   netWorth.Currency,
+				Rate:         rate,
+				Date:         rates.Date,
+				FetchedAt:    rates.FetchedAt.UTC(),
+			})
+		}
+
+		converted := models.RoundAmount(netWorth.Currency, wallet.Balance*rate)
+		netWorth.Total += converted
+
+		netWorth.Wallets = append(netWorth.Wallets, models.WalletNetWorth{
+			WalletID:  wallet.WalletID,
+			Name:      wallet.Name,
+			Currency:  wallet.Currency,
+			Balance:   wallet.Balance,
+			Converted: converted,
+		})
+	}
+
+	netWorth.Total = models.RoundAmount(netWorth.Currency, netWorth.Total)
+
+	slices.SortFunc(netWorth.Rates, func(a, b models.NetWorthRate) int {
+		return strings.Compare(a.FromCurrency, b.FromCurrency)
+	})
+
+	return netWorth, nil
+}
@@ -0,0 +1,74 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const defaultQuoteTTL = 30 * time.Second
+
+// CreateQuote locks the current rate of the pair for a transfer of the user. The rate is the bid,
+// as the sender sells the amount in their currency.
+func (s *Service) CreateQuote(ctx context.Context, userID models.UserID,
+	request models.QuoteRequest,
+) (models.Quote, error) {
+	if userID == models.UserID(uuid.Nil) {
+		return models.Quote{}, fmt.Errorf("%w", models.ErrUserID)
+	}
+
+	if err := request.Validate(); err != nil {
+		return models.Quote{}, fmt.Errorf("error validating quote request: %w", err)
+	}
+
+	quote := models.Quote{
+		ID:           models.QuoteID(uuid.New()),
+		UserID:       userID,
+		FromCurrency: strings.ToUpper(request.FromCurrency),
+		ToCurrency:   strings.ToUpper(request.ToCurrency),
+		Rate:         1,
+		ExpiresAt:    time.Now().Add(s.quoteTTL),
+	}
+
+	if quote.FromCurrency != quote.ToCurrency {
+		xr, err := s.xrClient.GetQuote(ctx, quote.FromCurrency, quote.ToCurrency)
+		if err != nil {
+			return models.Quote{}, fmt.Errorf("failed get rate: %w", err)
+		}
+
+		quote.Rate = xr.Bid
+	}
+
+	quote, err := s.wallets.CreateQuote(ctx, quote)
+	if err != nil {
+		return models.Quote{}, fmt.Errorf("failed create quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// quotedRate returns the rate of the quote the transfer carries, if the quote can still be used for it.
+// The store marks the quote used along with the transfer.
+func (s *Service) quotedRate(ctx context.Context, userID models.UserID, transaction models.Transaction,
+	toCurrency string,
+) (float64, error) {
+	quote, err := s.wallets.GetQuote(ctx, *transaction.QuoteID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed get quote: %w", err)
+	}
+
+	if !time.Now().Before(quote.ExpiresAt) {
+		return 0, models.ErrQuoteExpired
+	}
+
+	if !strings.EqualFold(quote.FromCurrency, transaction.Currency) || !strings.EqualFold(quote.ToCurrency, toCurrency) {
+		return 0, fmt.Errorf("%w: the quote is for %s to %s", models.ErrQuoteMismatch, quote.FromCurrency,
+			quote.ToCurrency)
+	}
+
+	return quote.Rate, nil
+}
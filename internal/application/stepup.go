@@ -0,0 +1,193 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/totp"
+	"github.com/google/uuid"
+)
+
+// StepUp configures the TOTP code required for withdrawals and transfers above Threshold,
+// measured in Currency. A zero threshold disables it.
+type StepUp struct {
+	Threshold float64
+	Currency  string
+	// TTL is how long a pending operation may be confirmed.
+	TTL time.Duration
+	// MaxAttempts invalid codes in a row lock the user out for Lockout.
+	MaxAttempts int
+	Lockout     time.Duration
+}
+
+const (
+	totpIssuer            = "Wallet"
+	defaultStepUpCurrency = "USD"
+	defaultOperationTTL   = 5 * time.Minute
+	defaultMaxAttempts    = 5
+	defaultLockout        = 15 * time.Minute
+)
+
+// EnrollTOTP creates a TOTP secret of the user. It is used for confirmations once ConfirmTOTP
+// accepted a code of it.
+func (s *Service) EnrollTOTP(ctx context.Context, userID models.UserID) (models.TOTPEnrollment, error) {
+	if userID == models.UserID(uuid.Nil) {
+		return models.TOTPEnrollment{}, fmt.Errorf("%w", models.ErrUserID)
+	}
+
+	user, err := s.wallets.GetUser(ctx, userID)
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("%w", err)
+	}
+
+	if err = s.wallets.CreateTOTP(ctx, userID, secret); err != nil {
+		return models.TOTPEnrollment{}, fmt.Errorf("failed create totp: %w", err)
+	}
+
+	account := user.UserEmail
+	if account == "" {
+		account = uuid.UUID(userID).String()
+	}
+
+	return models.TOTPEnrollment{
+		Secret: secret,
+		URL:    totp.URL(totpIssuer, account, secret),
+	}, nil
+}
+
+func (s *Service) ConfirmTOTP(ctx context.Context, userID models.UserID, code string) error {
+	factor, err := s.wallets.GetTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed get totp: %w", err)
+	}
+
+	if factor.Confirmed {
+		return models.ErrTOTPAlreadyEnrolled
+	}
+
+	return s.verifyCode(ctx, userID, factor, code)
+}
+
+// ConfirmOperation runs the pending operation as it was requested, once the code is valid.
+func (s *Service) ConfirmOperation(ctx context.Context, userID models.UserID, operationID models.OperationID,
+	code string,
+) error {
+	operation, err := s.wallets.GetPendingOperation(ctx, operationID, userID)
+	if err != nil {
+		return fmt.Errorf("failed get pending operation: %w", err)
+	}
+
+	factor, err := s.wallets.GetTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed get totp: %w", err)
+	}
+
+	if !factor.Confirmed {
+		return models.ErrTOTPNotEnrolled
+	}
+
+	if err = s.verifyCode(ctx, userID, factor, code); err != nil {
+		return err
+	}
+
+	if err = s.wallets.ConfirmPendingOperation(ctx, operationID); err != nil {
+		return fmt.Errorf("failed confirm pending operation: %w", err)
+	}
+
+	switch operation.Type {
+	case models.EventWithdraw:
+		return s.withdraw(ctx, userID, operation.Transaction, true)
+	case models.EventTransfer:
+		return s.transfer(ctx, userID, operation.Transaction, true)
+	default:
+		return fmt.Errorf("unknown pending operation type %q", operation.Type)
+	}
+}
+
+// verifyCode accepts a code once. Invalid codes are counted to lock brute-force attempts out.
+func (s *Service) verifyCode(ctx context.Context, userID models.UserID, factor models.TOTP, code string) error {
+	now := time.Now()
+
+	if factor.LockedUntil != nil && now.Before(*factor.LockedUntil) {
+		return models.ErrTooManyAttempts
+	}
+
+	step, ok := totp.Validate(factor.Secret, code, now)
+	if !ok || step <= factor.LastStep {
+		err := s.wallets.FailTOTPAttempt(ctx, userID, s.stepUp.MaxAttempts, now.Add(s.stepUp.Lockout))
+		if err != nil {
+			return fmt.Errorf("failed count totp attempt: %w", err)
+		}
+
+		return models.ErrWrongCode
+	}
+
+	if err := s.wallets.AcceptTOTPStep(ctx, userID, step); err != nil {
+		return fmt.Errorf("failed accept totp code: %w", err)
+	}
+
+	return nil
+}
+
+// requireConfirmation stores an operation above the threshold and returns the challenge to confirm it.
+// The stored transaction is what runs on confirmation, so it can't be changed in between.
+func (s *Service) requireConfirmation(ctx context.Context, userID models.UserID, operationType string,
+	transaction models.Transaction,
+) error {
+	if s.stepUp.Threshold <= 0 {
+		return nil
+	}
+
+	amount := transaction.Money
+
+	if transaction.Currency != s.stepUp.Currency {
+		rate, err := s.xrClient.GetRate(ctx, transaction.Currency, s.stepUp.Currency)
+		if err != nil {
+			return fmt.Errorf("failed get rate: %w", err)
+		}
+
+		amount *= rate
+	}
+
+	if amount <= s.stepUp.Threshold {
+		return nil
+	}
+
+	factor, err := s.wallets.GetTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed get totp: %w", err)
+	}
+
+	if !factor.Confirmed {
+		return models.ErrTOTPNotEnrolled
+	}
+
+	expiresAt := time.Now().Add(s.stepUp.TTL)
+
+	// a quoted transfer must still have its quote when it is confirmed
+	if transaction.QuoteID != nil {
+		if err = s.wallets.ExtendQuote(ctx, *transaction.QuoteID, userID, expiresAt); err != nil {
+			return fmt.Errorf("failed extend quote: %w", err)
+		}
+	}
+
+	operation, err := s.wallets.CreatePendingOperation(ctx, models.PendingOperation{
+		ID:          models.OperationID(uuid.New()),
+		UserID:      userID,
+		Type:        operationType,
+		Transaction: transaction,
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed create pending operation: %w", err)
+	}
+
+	return &models.ConfirmationRequiredError{Operation: operation}
+}
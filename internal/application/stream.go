@@ -0,0 +1,119 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	listenRetryDelay = 5 * time.Second
+	eventsRetention  = 7 * 24 * time.Hour
+	eventsBatchSize  = 100
+)
+
+// hub wakes up the streams of a user whenever a wallet event of that user is committed.
+type hub struct {
+	mu          sync.Mutex
+	subscribers map[models.UserID]map[chan struct{}]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subscribers: make(map[models.UserID]map[chan struct{}]struct{})}
+}
+
+func (h *hub) subscribe(userID models.UserID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan struct{}]struct{})
+	}
+
+	h.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[userID], ch)
+
+		if len(h.subscribers[userID]) == 0 {
+			delete(h.subscribers, userID)
+		}
+	}
+}
+
+func (h *hub) notify(userID models.UserID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[userID] {
+		wake(ch)
+	}
+}
+
+func (h *hub) notifyAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			wake(ch)
+		}
+	}
+}
+
+func wake(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) runHub(ctx context.Context) error {
+	for {
+		// Notifications sent while the listener was down are lost, so every stream re-reads its events.
+		s.hub.notifyAll()
+
+		if err := s.wallets.ListenWalletEvents(ctx, s.hub.notify); err != nil {
+			logrus.Warnf("failed to listen wallet events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (s *Service) SubscribeWalletEvents(userID models.UserID) (<-chan struct{}, func()) {
+	return s.hub.subscribe(userID)
+}
+
+func (s *Service) GetWalletEvents(ctx context.Context, userID models.UserID,
+	afterSeq int64,
+) ([]models.WalletEvent, error) {
+	events, err := s.wallets.GetWalletEvents(ctx, userID, afterSeq, eventsBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed get wallet events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *Service) GetLastWalletEventSeq(ctx context.Context, userID models.UserID) (int64, error) {
+	seq, err := s.wallets.GetLastWalletEventSeq(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed get last wallet event: %w", err)
+	}
+
+	return seq, nil
+}
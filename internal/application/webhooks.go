@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+const secretLen = 32
+
+func (s *Service) CreateWebhook(ctx context.Context, userInfo models.UserInfo,
+	webhook models.Webhook,
+) (models.Webhook, error) {
+	if err := webhook.Validate(); err != nil {
+		return models.Webhook{}, fmt.Errorf("%w", err)
+	}
+
+	if userInfo.Role != models.RoleAdmin {
+		webhook.UserID = &userInfo.UserID
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, secretLen)
+		if _, err := rand.Read(secret); err != nil {
+			return models.Webhook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	newWebhook, err := s.wallets.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("failed create webhook: %w", err)
+	}
+
+	return newWebhook, nil
+}
+
+func (s *Service) GetWebhooks(ctx context.Context, userInfo models.UserInfo) ([]models.Webhook, error) {
+	webhooks, err := s.wallets.GetWebhooks(ctx, ownerFilter(userInfo))
+	if err != nil {
+		return nil, fmt.Errorf("failed get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, userInfo models.UserInfo, webhookID models.WebhookID) error {
+	if err := s.wallets.DeleteWebhook(ctx, webhookID, ownerFilter(userInfo)); err != nil {
+		return fmt.Errorf("failed delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetWebhookDeliveries(ctx context.Context, userInfo models.UserInfo,
+	request models.GetWalletsRequest, webhookID models.WebhookID,
+) ([]models.WebhookDelivery, error) {
+	deliveries, err := s.wallets.GetWebhookDeliveries(ctx, request, webhookID, ownerFilter(userInfo))
+	if err != nil {
+		return nil, fmt.Errorf("failed get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Service) RedeliverWebhook(ctx context.Context, userInfo models.UserInfo, webhookID models.WebhookID,
+	deliveryID models.DeliveryID,
+) error {
+	if err := s.wallets.RedeliverWebhook(ctx, deliveryID, webhookID, ownerFilter(userInfo)); err != nil {
+		return fmt.Errorf("failed redeliver webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ownerFilter limits webhook access to the caller's own webhooks unless the caller is an admin.
+func ownerFilter(userInfo models.UserInfo) *models.UserID {
+	if userInfo.Role == models.RoleAdmin {
+		return nil
+	}
+
+	return &userInfo.UserID
+}
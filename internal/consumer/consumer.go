@@ -3,91 +3,363 @@ package consumer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/tracing"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	usersTopic        = "user_updates"
+	transactionsTopic = "transaction_updates"
+	walletsTopic      = "wallet_updates"
+	ratesTopic        = "xr_rates"
+	defaultGroupID    = "wallet-service"
+	retryDelay        = time.Second
+	ratesRetryDelay   = 10 * time.Second
+)
+
+var (
+	errNotRunning = errors.New("consumer is not running")
+	errMalformed  = errors.New("malformed message")
 )
 
 type infoSaver interface {
 	UpsertUser(ctx context.Context, users models.User) error
+	EnqueueWebhookDeliveries(ctx context.Context, event models.WebhookEvent) error
+}
+
+type rateSaver interface {
+	UpdateRates(table models.RateTable)
 }
 
 type Consumer struct {
 	infoSaver infoSaver
+	rateSaver rateSaver
+	client    sarama.Client
 	consumer  sarama.Consumer
+	group     sarama.ConsumerGroup
+	groupID   string
+	running   atomic.Bool
 }
 
 type Config struct {
 	Port string
+	// GroupID is the consumer group the replicas share, so each message is saved once.
+	GroupID string
 }
 
-func New(infoSaver infoSaver, cfg Config) (*Consumer, error) {
-	consumer, err := sarama.NewConsumer([]string{cfg.Port}, nil)
+func New(infoSaver infoSaver, rateSaver rateSaver, cfg Config) (*Consumer, error) {
+	client, err := sarama.NewClient([]string{cfg.Port}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating kafka client: %w", err)
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(client)
 	if err != nil {
 		return nil, fmt.Errorf("error creating new consumer: %w", err)
 	}
 
+	if cfg.GroupID == "" {
+		cfg.GroupID = defaultGroupID
+	}
+
+	group, err := sarama.NewConsumerGroupFromClient(cfg.GroupID, client)
+	if err != nil {
+		return nil, fmt.Errorf("error creating consumer group: %w", err)
+	}
+
 	return &Consumer{
 		infoSaver: infoSaver,
+		rateSaver: rateSaver,
+		client:    client,
 		consumer:  consumer,
+		group:     group,
+		groupID:   cfg.GroupID,
 	}, nil
 }
 
+// Run consumes the rates on every replica, and the other topics once per consumer group.
 func (c *Consumer) Run(ctx context.Context) error {
-	partConsumer, err := c.consumeUsers()
-	if err != nil {
-		return fmt.Errorf("error consuming users: %w", err)
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		return c.consumeGroup(ctx)
+	})
+
+	eg.Go(func() error {
+		c.runRates(ctx)
+
+		return nil
+	})
+
+	if err := eg.Wait(); err != nil {
+		return fmt.Errorf("consumer stopped: %w", err)
 	}
 
-	defer func() {
-		if err := partConsumer.Close(); err != nil {
-			logrus.Warnf("error closing consumer: %v", err)
+	return nil
+}
+
+// runRates saves the rate tables until the context is done. The rates are a cache with a sync fallback,
+// so nothing here stops the service: the topic is retried until it exists, and bad tables are skipped.
+func (c *Consumer) runRates(ctx context.Context) {
+	rateConsumer, err := c.consumeRates()
+	for err != nil {
+		logrus.Warnf("error consuming rates, retrying in %s: %v", ratesRetryDelay, err)
 
+		select {
+		case <-ctx.Done():
 			return
+		case <-time.After(ratesRetryDelay):
 		}
-	}()
+
+		rateConsumer, err = c.consumeRates()
+	}
+
+	defer closePartition(rateConsumer)
 
 	for {
 		select {
 		case <-ctx.Done():
+			return
+		case msg := <-rateConsumer.Messages():
+			if err := handle(ctx, msg, c.saveRates); err != nil {
+				logrus.Warnf("skipping rates %s: %v", eventKey(msg), err)
+			}
+		case err := <-rateConsumer.Errors():
+			if err != nil {
+				logrus.Warnf("error consuming rates: %v", err)
+			}
+		}
+	}
+}
+
+// consumeGroup rejoins the group after every rebalance until the context is done.
+func (c *Consumer) consumeGroup(ctx context.Context) error {
+	c.running.Store(true)
+	defer c.running.Store(false)
+
+	handler := groupHandler{consumer: c}
+
+	for {
+		err := c.group.Consume(ctx, []string{usersTopic, transactionsTopic, walletsTopic}, handler)
+		if ctx.Err() != nil {
 			return nil
-		case msg := <-partConsumer.Messages():
-			var users models.User
+		}
 
-			if err := json.Unmarshal(msg.Value, &users); err != nil {
-				return fmt.Errorf("error unmarshalling users: %w", err)
-			}
+		if err != nil {
+			return fmt.Errorf("error consuming group %s: %w", c.groupID, err)
+		}
+	}
+}
 
-			if err := c.infoSaver.UpsertUser(ctx, users); err != nil {
-				return fmt.Errorf("error upserting users: %w", err)
-			}
-		case err := <-partConsumer.Errors():
-			if err != nil {
-				return fmt.Errorf("error consuming users: %w", err)
+// groupHandler saves the messages of a claim in order, and marks each consumed once it is saved,
+// so the offsets committed for the group only cover saved messages.
+type groupHandler struct {
+	consumer *Consumer
+}
+
+func (h groupHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (h groupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim skips malformed messages. On any other error it ends the session after a delay,
+// and the message is consumed again once the group is rejoined.
+func (h groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		err := handle(session.Context(), msg, h.consumer.saver(msg.Topic))
+
+		switch {
+		case errors.Is(err, errMalformed):
+			logrus.Warnf("skipping message %s: %v", eventKey(msg), err)
+		case err != nil:
+			logrus.Warnf("error saving message %s, retrying: %v", eventKey(msg), err)
+
+			select {
+			case <-session.Context().Done():
+			case <-time.After(retryDelay):
 			}
+
+			return err
 		}
+
+		session.MarkMessage(msg, "")
+	}
+
+	return nil
+}
+
+func (c *Consumer) saver(topic string) func(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	switch topic {
+	case usersTopic:
+		return c.saveUser
+	case transactionsTopic:
+		return c.saveTransactionEvent
+	default:
+		return c.saveWalletEvent
+	}
+}
+
+// eventKey identifies a message across replicas, so that its webhook deliveries are enqueued once.
+func eventKey(msg *sarama.ConsumerMessage) string {
+	return fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+}
+
+// handle saves the message within a span continuing the trace of the producer.
+func handle(ctx context.Context, msg *sarama.ConsumerMessage,
+	save func(ctx context.Context, msg *sarama.ConsumerMessage) error,
+) (err error) {
+	ctx, span := tracing.StartConsumerSpan(ctx, msg)
+	defer func() { tracing.End(span, err) }()
+
+	return save(ctx, msg)
+}
+
+func (c *Consumer) saveUser(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var user models.User
+
+	if err := json.Unmarshal(msg.Value, &user); err != nil {
+		return fmt.Errorf("%w: error unmarshalling users: %w", errMalformed, err)
+	}
+
+	if err := c.infoSaver.UpsertUser(ctx, user); err != nil {
+		return fmt.Errorf("error upserting users: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Consumer) saveTransactionEvent(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var transaction models.Transaction
+
+	if err := json.Unmarshal(msg.Value, &transaction); err != nil {
+		return fmt.Errorf("%w: error unmarshalling transaction: %w", errMalformed, err)
+	}
+
+	event := models.WebhookEvent{
+		Key:       eventKey(msg),
+		Type:      transaction.Name,
+		WalletIDs: []models.WalletID{transaction.FirstWalletID},
+		Payload:   msg.Value,
+	}
+
+	if transaction.SecondWalletID != nil {
+		event.WalletIDs = append(event.WalletIDs, *transaction.SecondWalletID)
+	}
+
+	if err := c.infoSaver.EnqueueWebhookDeliveries(ctx, event); err != nil {
+		return fmt.Errorf("error enqueueing webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Consumer) saveWalletEvent(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	var wallet models.Wallet
+
+	if err := json.Unmarshal(msg.Value, &wallet); err != nil {
+		return fmt.Errorf("%w: error unmarshalling wallet: %w", errMalformed, err)
+	}
+
+	if !wallet.Archived {
+		return nil
+	}
+
+	event := models.WebhookEvent{
+		Key:       eventKey(msg),
+		Type:      models.EventWalletArchived,
+		WalletIDs: []models.WalletID{wallet.WalletID},
+		Payload:   msg.Value,
+	}
+
+	if err := c.infoSaver.EnqueueWebhookDeliveries(ctx, event); err != nil {
+		return fmt.Errorf("error enqueueing webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Consumer) saveRates(_ context.Context, msg *sarama.ConsumerMessage) error {
+	var table models.RateTable
+
+	if err := json.Unmarshal(msg.Value, &table); err != nil {
+		return fmt.Errorf("%w: error unmarshalling rates: %w", errMalformed, err)
+	}
+
+	if len(table.Quotes) == 0 || table.PublishedAt.IsZero() {
+		return fmt.Errorf("%w: rates without quotes or publication time", errMalformed)
+	}
+
+	c.rateSaver.UpdateRates(table)
+
+	return nil
+}
+
+func closePartition(partConsumer sarama.PartitionConsumer) {
+	if err := partConsumer.Close(); err != nil {
+		logrus.Warnf("error closing consumer: %v", err)
 	}
 }
 
 func (c *Consumer) Close() error {
+	if err := c.group.Close(); err != nil {
+		return fmt.Errorf("error closing consumer group: %w", err)
+	}
+
 	if err := c.consumer.Close(); err != nil {
 		return fmt.Errorf("error closing consumer: %w", err)
 	}
 
+	if err := c.client.Close(); err != nil {
+		return fmt.Errorf("error closing kafka client: %w", err)
+	}
+
 	return nil
 }
 
-func (c *Consumer) consumeMessage(topic string) (sarama.PartitionConsumer, error) {
-	partConsumer, err := c.consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
-	if err != nil {
-		return nil, fmt.Errorf("error consuming message from topic %s: %w", topic, err)
+// CheckBroker fetches cluster metadata, which fails when no broker is reachable.
+func (c *Consumer) CheckBroker(context.Context) error {
+	if err := c.client.RefreshMetadata(); err != nil {
+		return fmt.Errorf("error refreshing kafka metadata: %w", err)
 	}
 
-	return partConsumer, nil
+	return nil
+}
+
+func (c *Consumer) CheckRunning(context.Context) error {
+	if !c.running.Load() {
+		return errNotRunning
+	}
+
+	return nil
 }
 
-func (c *Consumer) consumeUsers() (sarama.PartitionConsumer, error) {
-	return c.consumeMessage("user_updates")
+// consumeRates starts from the latest rate table, so the rates are known without waiting for the next one.
+func (c *Consumer) consumeRates() (sarama.PartitionConsumer, error) {
+	newest, err := c.client.GetOffset(ratesTopic, 0, sarama.OffsetNewest)
+	if err != nil {
+		return nil, fmt.Errorf("error getting offset of topic %s: %w", ratesTopic, err)
+	}
+
+	offset := sarama.OffsetNewest
+	if newest > 0 {
+		offset = newest - 1
+	}
+
+	partConsumer, err := c.consumer.ConsumePartition(ratesTopic, 0, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error consuming message from topic %s: %w", ratesTopic, err)
+	}
+
+	return partConsumer, nil
 }
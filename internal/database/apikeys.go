@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Store) CreateAPIKey(ctx context.Context, apiKey models.APIKey, keyHash string) (models.APIKey, error) {
+	query := `INSERT INTO api_keys
+    (id, name, user_id, key_hash, prefix, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, created_at`
+
+	err := s.db.QueryRow(ctx, query, uuid.New(), apiKey.Name, apiKey.UserID, keyHash, apiKey.Prefix,
+		apiKey.Scopes, apiKey.ExpiresAt).Scan(
+		&apiKey.ID,
+		&apiKey.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return models.APIKey{}, models.ErrUserNotFound
+		}
+
+		return models.APIKey{}, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+func (s *Store) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT id, name, user_id, prefix, scopes, expires_at, created_at
+FROM api_keys WHERE archived = false ORDER BY created_at`
+
+	rows, err := s.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	defer rows.Close()
+
+	apiKeys := []models.APIKey{}
+
+	for rows.Next() {
+		var apiKey models.APIKey
+		if err = rows.Scan(
+			&apiKey.ID,
+			&apiKey.Name,
+			&apiKey.UserID,
+			&apiKey.Prefix,
+			&apiKey.Scopes,
+			&apiKey.ExpiresAt,
+			&apiKey.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan api keys: %w", err)
+		}
+
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// GetAPIKeyByHash returns the active, unexpired key with the hash.
+func (s *Store) GetAPIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	var apiKey models.APIKey
+
+	query := `SELECT id, name, user_id, prefix, scopes, expires_at, created_at
+FROM api_keys WHERE key_hash = $1 AND archived = false AND (expires_at IS NULL OR expires_at > NOW())`
+
+	err := s.db.QueryRow(ctx, query, keyHash).Scan(
+		&apiKey.ID,
+		&apiKey.Name,
+		&apiKey.UserID,
+		&apiKey.Prefix,
+		&apiKey.Scopes,
+		&apiKey.ExpiresAt,
+		&apiKey.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, models.ErrAPIKeyNotFound
+		}
+
+		return models.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	return apiKey, nil
+}
+
+func (s *Store) DeleteAPIKey(ctx context.Context, apiKeyID models.APIKeyID) error {
+	query := `UPDATE api_keys SET archived = true WHERE id = $1 AND archived = false`
+
+	res, err := s.db.Exec(ctx, query, apiKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete api key: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete api key: %w", models.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
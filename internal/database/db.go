@@ -4,9 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"errors"
 	"fmt"
 
 	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	migrate "github.com/rubenv/sql-migrate"
 	"github.com/sirupsen/logrus"
@@ -26,7 +29,14 @@ type Config struct {
 var migrations embed.FS
 
 func New(ctx context.Context, cfg Config) (*Store, error) {
-	db, err := pgxpool.New(ctx, cfg.Dsn)
+	poolConfig, err := pgxpool.ParseConfig(cfg.Dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database dsn: %w", err)
+	}
+
+	poolConfig.ConnConfig.Tracer = queryTracer{}
+
+	db, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -44,6 +54,10 @@ func New(ctx context.Context, cfg Config) (*Store, error) {
 	}, nil
 }
 
+func (s *Store) Close() {
+	s.db.Close()
+}
+
 func (s *Store) Migrate(direction migrate.MigrationDirection) error {
 	db, err := sql.Open("pgx", s.dsn)
 	if err != nil {
@@ -87,21 +101,99 @@ func (s *Store) Migrate(direction migrate.MigrationDirection) error {
 }
 
 func (s *Store) UpsertUser(ctx context.Context, users models.User) error {
-	query := `INSERT INTO users (id, status, archived, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5)
+	query := `INSERT INTO users (id, name, surname, age, gender, email, country, engagement_source,
+                   status, archived, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 ON CONFLICT (id) DO UPDATE SET 
+    name = excluded.name,
+    surname = excluded.surname,
+    age = excluded.age,
+    gender = excluded.gender,
+    email = excluded.email,
+    country = excluded.country,
+    engagement_source = excluded.engagement_source,
     status = excluded.status, 
     archived = excluded.archived,
-    updated_at = NOW()`
-
-	_, err := s.db.Exec(ctx, query, users.UserID, users.Status, users.Archived, users.CreatedAt, users.UpdatedAt)
+    updated_at = excluded.updated_at
+WHERE users.updated_at < excluded.updated_at`
+
+	res, err := s.db.Exec(ctx, query,
+		users.UserID,
+		users.UserName,
+		users.UserSurname,
+		users.UserAge,
+		users.UserGender,
+		users.UserEmail,
+		users.Country,
+		users.EngagementSource,
+		users.Status,
+		users.Archived,
+		users.CreatedAt,
+		users.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to upsert users: %w", err)
 	}
 
+	if res.RowsAffected() == 0 {
+		s.metrics.staleUserUpdates.Inc()
+		logrus.WithContext(ctx).Debugf("skipped stale update of user %s from %v", uuid.UUID(users.UserID), users.UpdatedAt)
+	}
+
 	return nil
 }
 
+// CreateUser adds the user unless a user with its id exists, which is left as it is.
+// It reports whether the user was added.
+func (s *Store) CreateUser(ctx context.Context, user models.User) (bool, error) {
+	query := `INSERT INTO users (id, email, status, archived, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (id) DO NOTHING`
+
+	res, err := s.db.Exec(ctx, query,
+		user.UserID,
+		user.UserEmail,
+		user.Status,
+		user.Archived,
+		user.CreatedAt,
+		user.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, userID models.UserID) (models.User, error) {
+	var user models.User
+
+	query := `SELECT id, name, surname, age, gender, email, country, engagement_source,
+       status, archived, created_at, updated_at
+FROM users WHERE id = $1`
+
+	err := s.db.QueryRow(ctx, query, userID).Scan(
+		&user.UserID,
+		&user.UserName,
+		&user.UserSurname,
+		&user.UserAge,
+		&user.UserGender,
+		&user.UserEmail,
+		&user.Country,
+		&user.EngagementSource,
+		&user.Status,
+		&user.Archived,
+		&user.CreatedAt,
+		&user.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("failed to read user info: %w", models.ErrUserNotFound)
+		}
+
+		return models.User{}, fmt.Errorf("failed to read user info: %w", err)
+	}
+
+	return user, nil
+}
+
 func (s *Store) Truncate(ctx context.Context, tables ...string) error {
 	for _, table := range tables {
 		if _, err := s.db.Exec(ctx, "DELETE FROM "+table); err != nil {
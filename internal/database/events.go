@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	walletEventsChannel      = "wallet_events"
+	walletEventsAddedChannel = "wallet_events_added"
+	// numberEventsInterval numbers the events left unnumbered while an older transaction was running.
+	numberEventsInterval = time.Second
+)
+
+func (s *Store) GetWalletEvents(ctx context.Context, userID models.UserID, afterSeq int64,
+	limit int,
+) ([]models.WalletEvent, error) {
+	query := `SELECT seq, wallet_id, type, payload, created_at
+FROM wallet_events WHERE user_id = $1 AND seq > $2 ORDER BY seq LIMIT $3`
+
+	rows, err := s.db.Query(ctx, query, userID, afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet events: %w", err)
+	}
+
+	defer rows.Close()
+
+	var events []models.WalletEvent
+
+	for rows.Next() {
+		var event models.WalletEvent
+		if err = rows.Scan(
+			&event.Seq,
+			&event.WalletID,
+			&event.Type,
+			&event.Payload,
+			&event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet events: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get wallet events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *Store) GetLastWalletEventSeq(ctx context.Context, userID models.UserID) (int64, error) {
+	var seq int64
+
+	query := `SELECT (SELECT COALESCE(max(seq), 0) FROM wallet_events) FROM users WHERE id = $1`
+
+	if err := s.db.QueryRow(ctx, query, userID).Scan(&seq); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, fmt.Errorf("failed to read last wallet event: %w", models.ErrUserNotFound)
+		}
+
+		return 0, fmt.Errorf("failed to read last wallet event: %w", err)
+	}
+
+	return seq, nil
+}
+
+func (s *Store) DeleteWalletEvents(ctx context.Context, before time.Time) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM wallet_events WHERE created_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to delete wallet events: %w", err)
+	}
+
+	return nil
+}
+
+// NumberWalletEvents numbers the added events that no running transaction can precede any more,
+// and notifies their owners.
+func (s *Store) NumberWalletEvents(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx, `SELECT number_wallet_events()`); err != nil {
+		return fmt.Errorf("failed to number wallet events: %w", err)
+	}
+
+	return nil
+}
+
+// ListenWalletEvents numbers the added wallet events and calls notify with the owner of every numbered
+// event, including events committed by other replicas, until ctx is done.
+func (s *Store) ListenWalletEvents(ctx context.Context, notify func(userID models.UserID)) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	defer conn.Release()
+
+	for _, channel := range []string{walletEventsChannel, walletEventsAddedChannel} {
+		if _, err = conn.Exec(ctx, "LISTEN "+channel); err != nil {
+			return fmt.Errorf("failed to listen wallet events: %w", err)
+		}
+	}
+
+	for {
+		if err = s.NumberWalletEvents(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		notification, err := s.waitForNotification(ctx, conn.Conn())
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("failed to wait for wallet events: %w", err)
+		}
+
+		if notification == nil || notification.Channel != walletEventsChannel {
+			continue
+		}
+
+		userID, err := uuid.Parse(notification.Payload)
+		if err != nil {
+			continue
+		}
+
+		notify(models.UserID(userID))
+	}
+}
+
+// waitForNotification returns a nil notification when none arrived within numberEventsInterval.
+func (s *Store) waitForNotification(ctx context.Context, conn *pgx.Conn) (*pgconn.Notification, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, numberEventsInterval)
+	defer cancel()
+
+	notification, err := conn.WaitForNotification(waitCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(waitCtx.Err(), context.DeadlineExceeded) {
+		return nil, nil //nolint:nilnil
+	}
+
+	return notification, err //nolint:wrapcheck
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errPendingMigrations = errors.New("migrations not applied")
+
+func (s *Store) Ping(ctx context.Context) error {
+	if err := s.db.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return nil
+}
+
+// CheckMigrations reports the embedded migrations missing from the sql-migrate table.
+func (s *Store) CheckMigrations(ctx context.Context) error {
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	rows, err := s.db.Query(ctx, `SELECT id FROM gorp_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	defer rows.Close()
+
+	applied := make(map[string]struct{})
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return fmt.Errorf("failed to scan applied migrations: %w", err)
+		}
+
+		applied[id] = struct{}{}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	var pending []string
+
+	for _, entry := range entries {
+		if _, ok := applied[entry.Name()]; !ok {
+			pending = append(pending, entry.Name())
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %s", errPendingMigrations, strings.Join(pending, ", "))
+	}
+
+	return nil
+}
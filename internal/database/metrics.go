@@ -6,7 +6,8 @@ import (
 )
 
 type metrics struct {
-	txDuration *prometheus.HistogramVec
+	txDuration       *prometheus.HistogramVec
+	staleUserUpdates prometheus.Counter
 }
 
 const (
@@ -24,6 +25,13 @@ func newMetric() *metrics {
 				Help:      "Duration of transaction.",
 			},
 			[]string{"endpoint"}),
+		staleUserUpdates: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "stale_user_updates_total",
+				Help:      "Number of user updates skipped because a newer version is already stored.",
+			}),
 	}
 
 	return &metric
@@ -0,0 +1,21 @@
+-- +migrate Up
+
+ALTER TABLE users
+    ADD COLUMN name              VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN surname           VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN age               INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN gender            VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN email             VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN country           VARCHAR NOT NULL DEFAULT '',
+    ADD COLUMN engagement_source VARCHAR NOT NULL DEFAULT '';
+
+-- +migrate Down
+
+ALTER TABLE users
+    DROP COLUMN name,
+    DROP COLUMN surname,
+    DROP COLUMN age,
+    DROP COLUMN gender,
+    DROP COLUMN email,
+    DROP COLUMN country,
+    DROP COLUMN engagement_source;
@@ -0,0 +1,31 @@
+-- +migrate Up
+
+CREATE TABLE webhooks (
+    id          UUID                     NOT NULL UNIQUE PRIMARY KEY,
+    user_id     UUID                     REFERENCES users (id),
+    url         VARCHAR                  NOT NULL,
+    secret      VARCHAR                  NOT NULL,
+    event_types VARCHAR[]                NOT NULL,
+    archived    BOOLEAN                  NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries (
+    id              UUID                     NOT NULL UNIQUE PRIMARY KEY,
+    webhook_id      UUID                     NOT NULL REFERENCES webhooks (id),
+    event_type      VARCHAR                  NOT NULL,
+    payload         JSONB                    NOT NULL,
+    status          VARCHAR                  NOT NULL DEFAULT 'pending',
+    attempts        INTEGER                  NOT NULL DEFAULT 0,
+    response_code   INTEGER,
+    last_error      VARCHAR,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +migrate Down
+
+DROP TABLE webhook_deliveries CASCADE;
+DROP TABLE webhooks CASCADE;
@@ -0,0 +1,92 @@
+-- +migrate Up
+
+ALTER TABLE users ADD COLUMN event_seq BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE wallet_events (
+    user_id    UUID                     NOT NULL REFERENCES users (id),
+    seq        BIGINT                   NOT NULL,
+    wallet_id  UUID                     NOT NULL REFERENCES wallets (id),
+    type       VARCHAR                  NOT NULL,
+    payload    JSONB                    NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, seq)
+);
+
+-- users.event_seq is incremented under the user row lock, so events of one user
+-- are committed in sequence order and readers can resume after the last seen seq.
+
+-- +migrate StatementBegin
+CREATE FUNCTION add_wallet_event(owner UUID, wallet UUID, event_type VARCHAR, event_payload JSONB)
+    RETURNS VOID AS
+$$
+DECLARE
+    next_seq BIGINT;
+BEGIN
+    UPDATE users SET event_seq = event_seq + 1 WHERE id = owner RETURNING event_seq INTO next_seq;
+
+    INSERT INTO wallet_events (user_id, seq, wallet_id, type, payload)
+    VALUES (owner, next_seq, wallet, event_type, event_payload);
+
+    PERFORM pg_notify('wallet_events', owner::text);
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE FUNCTION wallet_balance_event() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM add_wallet_event(NEW.user_id, NEW.id, 'balance', jsonb_build_object(
+        'walletId', NEW.id,
+        'currency', NEW.currency,
+        'balance', NEW.balance,
+        'updatedAt', NEW.updated_at));
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE FUNCTION wallet_transaction_event() RETURNS TRIGGER AS
+$$
+DECLARE
+    wallet RECORD;
+BEGIN
+    FOR wallet IN SELECT id, user_id FROM wallets WHERE id IN (NEW.first_wallet, NEW.second_wallet)
+    LOOP
+        PERFORM add_wallet_event(wallet.user_id, wallet.id, 'transaction', jsonb_build_object(
+            'id', NEW.id,
+            'name', NEW.name,
+            'firstWallet', NEW.first_wallet,
+            'secondWallet', NEW.second_wallet,
+            'money', NEW.money,
+            'currency', NEW.currency,
+            'createdAt', NEW.created_at));
+    END LOOP;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER wallets_balance_event
+    AFTER UPDATE OF balance ON wallets
+    FOR EACH ROW
+    WHEN (OLD.balance IS DISTINCT FROM NEW.balance)
+EXECUTE FUNCTION wallet_balance_event();
+
+CREATE TRIGGER transactions_event
+    AFTER INSERT ON transactions
+    FOR EACH ROW
+EXECUTE FUNCTION wallet_transaction_event();
+
+-- +migrate Down
+
+DROP TRIGGER transactions_event ON transactions;
+DROP TRIGGER wallets_balance_event ON wallets;
+DROP FUNCTION wallet_transaction_event();
+DROP FUNCTION wallet_balance_event();
+DROP FUNCTION add_wallet_event(UUID, UUID, VARCHAR, JSONB);
+DROP TABLE wallet_events CASCADE;
+ALTER TABLE users DROP COLUMN event_seq;
@@ -0,0 +1,12 @@
+-- +migrate Up
+
+CREATE UNLOGGED TABLE rate_limits (
+    key        VARCHAR                  NOT NULL PRIMARY KEY,
+    tokens     DOUBLE PRECISION         NOT NULL,
+    allowed    BOOLEAN                  NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +migrate Down
+
+DROP TABLE rate_limits;
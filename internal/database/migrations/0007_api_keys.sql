@@ -0,0 +1,17 @@
+-- +migrate Up
+
+CREATE TABLE api_keys (
+    id         UUID                     NOT NULL UNIQUE PRIMARY KEY,
+    name       VARCHAR                  NOT NULL,
+    user_id    UUID                     REFERENCES users (id),
+    key_hash   VARCHAR                  NOT NULL UNIQUE,
+    prefix     VARCHAR                  NOT NULL,
+    scopes     VARCHAR[]                NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    archived   BOOLEAN                  NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- +migrate Down
+
+DROP TABLE api_keys;
@@ -0,0 +1,17 @@
+-- +migrate Up
+
+CREATE TABLE revoked_tokens (
+    jti        VARCHAR                  NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE revoked_users (
+    user_id        UUID                     NOT NULL PRIMARY KEY,
+    revoked_before TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at     TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- +migrate Down
+
+DROP TABLE revoked_users;
+DROP TABLE revoked_tokens;
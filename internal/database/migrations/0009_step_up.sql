@@ -0,0 +1,29 @@
+-- +migrate Up
+
+CREATE TABLE user_totp (
+    user_id         UUID                     NOT NULL PRIMARY KEY REFERENCES users (id),
+    secret          VARCHAR                  NOT NULL,
+    confirmed       BOOLEAN                  NOT NULL DEFAULT FALSE,
+    last_step       BIGINT                   NOT NULL DEFAULT 0,
+    failed_attempts INTEGER                  NOT NULL DEFAULT 0,
+    locked_until    TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TABLE pending_operations (
+    id           UUID                     NOT NULL UNIQUE PRIMARY KEY,
+    user_id      UUID                     NOT NULL REFERENCES users (id),
+    type         VARCHAR                  NOT NULL,
+    transaction  JSONB                    NOT NULL,
+    status       VARCHAR                  NOT NULL DEFAULT 'pending',
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX pending_operations_expires_at_idx ON pending_operations (expires_at);
+
+-- +migrate Down
+
+DROP TABLE pending_operations;
+DROP TABLE user_totp;
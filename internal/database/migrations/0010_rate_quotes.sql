@@ -0,0 +1,18 @@
+-- +migrate Up
+
+CREATE TABLE rate_quotes (
+    id            UUID                     NOT NULL UNIQUE PRIMARY KEY,
+    user_id       UUID                     NOT NULL REFERENCES users (id),
+    from_currency VARCHAR                  NOT NULL,
+    to_currency   VARCHAR                  NOT NULL,
+    rate          NUMERIC                  NOT NULL CHECK ( rate > 0 ),
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at       TIMESTAMP WITH TIME ZONE,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX rate_quotes_expires_at_idx ON rate_quotes (expires_at);
+
+-- +migrate Down
+
+DROP TABLE rate_quotes;
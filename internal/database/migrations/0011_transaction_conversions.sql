@@ -0,0 +1,17 @@
+-- +migrate Up
+
+ALTER TABLE transactions
+    ADD COLUMN wallet_currency VARCHAR,
+    ADD COLUMN wallet_money    NUMERIC CHECK ( wallet_money > 0 ),
+    ADD COLUMN rate            NUMERIC CHECK ( rate > 0 ),
+    ADD COLUMN mid_rate        NUMERIC CHECK ( mid_rate > 0 ),
+    ADD COLUMN fee             NUMERIC CHECK ( fee >= 0 );
+
+-- +migrate Down
+
+ALTER TABLE transactions
+    DROP COLUMN wallet_currency,
+    DROP COLUMN wallet_money,
+    DROP COLUMN rate,
+    DROP COLUMN mid_rate,
+    DROP COLUMN fee;
@@ -0,0 +1,11 @@
+-- +migrate Up
+
+ALTER TABLE webhook_deliveries ADD COLUMN event_key VARCHAR;
+
+CREATE UNIQUE INDEX webhook_deliveries_event_key_idx ON webhook_deliveries (webhook_id, event_key);
+
+-- +migrate Down
+
+DROP INDEX webhook_deliveries_event_key_idx;
+
+ALTER TABLE webhook_deliveries DROP COLUMN event_key;
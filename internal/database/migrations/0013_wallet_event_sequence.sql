@@ -0,0 +1,99 @@
+-- +migrate Up
+
+-- Events are added unnumbered by the money transactions, without locking anything but the wallets.
+-- number_wallet_events numbers them from one sequence once every transaction that could still add
+-- an earlier event has ended, so readers can resume after the last seen seq without missing events.
+
+CREATE SEQUENCE wallet_events_seq;
+
+ALTER TABLE wallet_events DROP CONSTRAINT wallet_events_pkey;
+ALTER TABLE wallet_events ALTER COLUMN seq DROP NOT NULL;
+ALTER TABLE wallet_events ADD COLUMN id BIGSERIAL PRIMARY KEY;
+ALTER TABLE wallet_events ADD COLUMN tx_id XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+UPDATE wallet_events e SET seq = n.seq
+FROM (SELECT id, nextval('wallet_events_seq') AS seq FROM wallet_events ORDER BY created_at, id) n
+WHERE e.id = n.id;
+
+CREATE UNIQUE INDEX wallet_events_seq_idx ON wallet_events (seq);
+CREATE INDEX wallet_events_user_seq_idx ON wallet_events (user_id, seq);
+CREATE INDEX wallet_events_unnumbered_idx ON wallet_events (tx_id, id) WHERE seq IS NULL;
+
+ALTER TABLE users DROP COLUMN event_seq;
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION add_wallet_event(owner UUID, wallet UUID, event_type VARCHAR, event_payload JSONB)
+    RETURNS VOID AS
+$$
+BEGIN
+    INSERT INTO wallet_events (user_id, wallet_id, type, payload)
+    VALUES (owner, wallet, event_type, event_payload);
+
+    PERFORM pg_notify('wallet_events_added', '');
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE FUNCTION number_wallet_events() RETURNS VOID AS
+$$
+DECLARE
+    event  RECORD;
+    owner  UUID;
+    owners UUID[] := '{}';
+BEGIN
+    -- one numbering at a time, so seqs are committed in order
+    PERFORM pg_advisory_xact_lock(hashtext('number_wallet_events'));
+
+    FOR event IN SELECT id, user_id FROM wallet_events
+                 WHERE seq IS NULL AND tx_id < pg_snapshot_xmin(pg_current_snapshot())
+                 ORDER BY tx_id, id
+    LOOP
+        UPDATE wallet_events SET seq = nextval('wallet_events_seq') WHERE id = event.id;
+        owners := array_append(owners, event.user_id);
+    END LOOP;
+
+    FOR owner IN SELECT DISTINCT unnest(owners)
+    LOOP
+        PERFORM pg_notify('wallet_events', owner::text);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+DROP FUNCTION number_wallet_events();
+
+DELETE FROM wallet_events WHERE seq IS NULL;
+
+ALTER TABLE users ADD COLUMN event_seq BIGINT NOT NULL DEFAULT 0;
+
+UPDATE users u SET event_seq = e.seq FROM (SELECT user_id, max(seq) AS seq FROM wallet_events GROUP BY user_id) e
+WHERE u.id = e.user_id;
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION add_wallet_event(owner UUID, wallet UUID, event_type VARCHAR, event_payload JSONB)
+    RETURNS VOID AS
+$$
+DECLARE
+    next_seq BIGINT;
+BEGIN
+    UPDATE users SET event_seq = event_seq + 1 WHERE id = owner RETURNING event_seq INTO next_seq;
+
+    INSERT INTO wallet_events (user_id, seq, wallet_id, type, payload)
+    VALUES (owner, next_seq, wallet, event_type, event_payload);
+
+    PERFORM pg_notify('wallet_events', owner::text);
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+DROP INDEX wallet_events_unnumbered_idx;
+DROP INDEX wallet_events_user_seq_idx;
+DROP INDEX wallet_events_seq_idx;
+ALTER TABLE wallet_events DROP COLUMN tx_id;
+ALTER TABLE wallet_events DROP COLUMN id;
+ALTER TABLE wallet_events ALTER COLUMN seq SET NOT NULL;
+ALTER TABLE wallet_events ADD PRIMARY KEY (user_id, seq);
+DROP SEQUENCE wallet_events_seq;
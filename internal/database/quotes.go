@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Store) CreateQuote(ctx context.Context, quote models.Quote) (models.Quote, error) {
+	query := `INSERT INTO rate_quotes (id, user_id, from_currency, to_currency, rate, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+
+	err := s.db.QueryRow(ctx, query, quote.ID, quote.UserID, quote.FromCurrency, quote.ToCurrency, quote.Rate,
+		quote.ExpiresAt).Scan(&quote.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return models.Quote{}, models.ErrUserNotFound
+		}
+
+		return models.Quote{}, fmt.Errorf("failed to create quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+// GetQuote returns the quote of the user, or ErrQuoteUsed if a transfer has used it.
+func (s *Store) GetQuote(ctx context.Context, quoteID models.QuoteID, userID models.UserID) (models.Quote, error) {
+	var (
+		quote  models.Quote
+		usedAt *time.Time
+	)
+
+	query := `SELECT id, user_id, from_currency, to_currency, rate, expires_at, used_at, created_at FROM rate_quotes
+WHERE id = $1 AND user_id = $2`
+
+	err := s.db.QueryRow(ctx, query, quoteID, userID).Scan(
+		&quote.ID,
+		&quote.UserID,
+		&quote.FromCurrency,
+		&quote.ToCurrency,
+		&quote.Rate,
+		&quote.ExpiresAt,
+		&usedAt,
+		&quote.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Quote{}, models.ErrQuoteNotFound
+		}
+
+		return models.Quote{}, fmt.Errorf("failed to get quote: %w", err)
+	}
+
+	if usedAt != nil {
+		return models.Quote{}, models.ErrQuoteUsed
+	}
+
+	return quote, nil
+}
+
+// ExtendQuote keeps an unused quote until expiresAt, so that it outlives the confirmation of its transfer.
+func (s *Store) ExtendQuote(ctx context.Context, quoteID models.QuoteID, userID models.UserID,
+	expiresAt time.Time,
+) error {
+	query := `UPDATE rate_quotes SET expires_at = GREATEST(expires_at, $3)
+WHERE id = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > NOW()`
+
+	res, err := s.db.Exec(ctx, query, quoteID, userID, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to extend quote: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return models.ErrQuoteExpired
+	}
+
+	return nil
+}
+
+// useQuoteTx marks the quote used within the transfer, so that it is used once even by concurrent transfers.
+func (s *Store) useQuoteTx(ctx context.Context, quoteID models.QuoteID, dbTx pgx.Tx) error {
+	query := `UPDATE rate_quotes SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND expires_at > NOW()`
+
+	res, err := dbTx.Exec(ctx, query, quoteID)
+	if err != nil {
+		return fmt.Errorf("failed to use quote: %w", err)
+	}
+
+	if res.RowsAffected() != 0 {
+		return nil
+	}
+
+	var used bool
+
+	err = dbTx.QueryRow(ctx, `SELECT used_at IS NOT NULL FROM rate_quotes WHERE id = $1`, quoteID).Scan(&used)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return models.ErrQuoteNotFound
+	case err != nil:
+		return fmt.Errorf("failed to get quote: %w", err)
+	case used:
+		return models.ErrQuoteUsed
+	default:
+		return models.ErrQuoteExpired
+	}
+}
+
+func (s *Store) DeleteQuotes(ctx context.Context, before time.Time) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM rate_quotes WHERE expires_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to delete quotes: %w", err)
+	}
+
+	return nil
+}
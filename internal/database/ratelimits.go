@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+// TakeRateLimitToken refills the token bucket of the key and takes a token from it in one
+// statement, so that replicas sharing the database share the budget.
+func (s *Store) TakeRateLimitToken(ctx context.Context, key string, limit models.RateLimit) (bool, float64, error) {
+	query := `INSERT INTO rate_limits AS r (key, tokens, allowed, updated_at)
+VALUES ($1, $3::float8 - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+    tokens = CASE
+        WHEN LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2::float8) >= 1
+        THEN LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2::float8) - 1
+        ELSE LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2::float8) END,
+    allowed = LEAST($3::float8, r.tokens + EXTRACT(EPOCH FROM now() - r.updated_at)::float8 * $2::float8) >= 1,
+    updated_at = now()
+RETURNING allowed, tokens`
+
+	var (
+		allowed bool
+		tokens  float64
+	)
+
+	if err := s.db.QueryRow(ctx, query, key, limit.Rate, limit.Burst).Scan(&allowed, &tokens); err != nil {
+		return false, 0, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return allowed, tokens, nil
+}
+
+func (s *Store) DeleteRateLimits(ctx context.Context, before time.Time) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM rate_limits WHERE updated_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to delete rate limits: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+func (s *Store) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING`
+
+	if _, err := s.db.Exec(ctx, query, jti, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserTokens keeps the latest revocation time of the user.
+func (s *Store) RevokeUserTokens(ctx context.Context, userID models.UserID, before, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_users AS r (user_id, revoked_before, expires_at) VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET
+    revoked_before = GREATEST(r.revoked_before, EXCLUDED.revoked_before),
+    expires_at = GREATEST(r.expires_at, EXCLUDED.expires_at)`
+
+	if _, err := s.db.Exec(ctx, query, userID, before, expiresAt); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) GetRevocations(ctx context.Context) (models.Revocations, error) {
+	revocations := models.Revocations{
+		Tokens: make(map[string]struct{}),
+		Users:  make(map[models.UserID]time.Time),
+	}
+
+	rows, err := s.db.Query(ctx, `SELECT jti FROM revoked_tokens WHERE expires_at > NOW()`)
+	if err != nil {
+		return models.Revocations{}, fmt.Errorf("failed to get revoked tokens: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var jti string
+		if err = rows.Scan(&jti); err != nil {
+			return models.Revocations{}, fmt.Errorf("failed to scan revoked tokens: %w", err)
+		}
+
+		revocations.Tokens[jti] = struct{}{}
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.Revocations{}, fmt.Errorf("failed to get revoked tokens: %w", err)
+	}
+
+	rows, err = s.db.Query(ctx, `SELECT user_id, revoked_before FROM revoked_users WHERE expires_at > NOW()`)
+	if err != nil {
+		return models.Revocations{}, fmt.Errorf("failed to get revoked users: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userID models.UserID
+			before time.Time
+		)
+
+		if err = rows.Scan(&userID, &before); err != nil {
+			return models.Revocations{}, fmt.Errorf("failed to scan revoked users: %w", err)
+		}
+
+		revocations.Users[userID] = before
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.Revocations{}, fmt.Errorf("failed to get revoked users: %w", err)
+	}
+
+	return revocations, nil
+}
+
+func (s *Store) DeleteExpiredRevocations(ctx context.Context) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("failed to delete revoked tokens: %w", err)
+	}
+
+	if _, err := s.db.Exec(ctx, `DELETE FROM revoked_users WHERE expires_at <= NOW()`); err != nil {
+		return fmt.Errorf("failed to delete revoked users: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CreateTOTP stores a new secret of the user, replacing a secret that was never confirmed.
+func (s *Store) CreateTOTP(ctx context.Context, userID models.UserID, secret string) error {
+	query := `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_step = 0, created_at = now()
+WHERE user_totp.confirmed = false`
+
+	res, err := s.db.Exec(ctx, query, userID, secret)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return models.ErrUserNotFound
+		}
+
+		return fmt.Errorf("failed to create totp: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return models.ErrTOTPAlreadyEnrolled
+	}
+
+	return nil
+}
+
+func (s *Store) GetTOTP(ctx context.Context, userID models.UserID) (models.TOTP, error) {
+	var totp models.TOTP
+
+	query := `SELECT secret, confirmed, last_step, locked_until FROM user_totp WHERE user_id = $1`
+
+	err := s.db.QueryRow(ctx, query, userID).Scan(
+		&totp.Secret,
+		&totp.Confirmed,
+		&totp.LastStep,
+		&totp.LockedUntil)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.TOTP{}, models.ErrTOTPNotEnrolled
+		}
+
+		return models.TOTP{}, fmt.Errorf("failed to get totp: %w", err)
+	}
+
+	return totp, nil
+}
+
+// AcceptTOTPStep records a valid code of the step and confirms the secret. It fails with
+// ErrWrongCode if a code of the step or a later one was already accepted, or the user is locked out.
+func (s *Store) AcceptTOTPStep(ctx context.Context, userID models.UserID, step int64) error {
+	query := `UPDATE user_totp SET confirmed = true, last_step = $2, failed_attempts = 0, locked_until = NULL
+WHERE user_id = $1 AND last_step < $2 AND (locked_until IS NULL OR locked_until <= NOW())`
+
+	res, err := s.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to accept totp code: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return models.ErrWrongCode
+	}
+
+	return nil
+}
+
+// FailTOTPAttempt counts an invalid code and locks the user out until lockedUntil
+// once maxAttempts invalid codes were entered in a row.
+func (s *Store) FailTOTPAttempt(ctx context.Context, userID models.UserID, maxAttempts int,
+	lockedUntil time.Time,
+) error {
+	query := `UPDATE user_totp SET
+    failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+    locked_until = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+WHERE user_id = $1`
+
+	if _, err := s.db.Exec(ctx, query, userID, maxAttempts, lockedUntil); err != nil {
+		return fmt.Errorf("failed to count totp attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) CreatePendingOperation(ctx context.Context,
+	operation models.PendingOperation,
+) (models.PendingOperation, error) {
+	query := `INSERT INTO pending_operations (id, user_id, type, transaction, expires_at)
+VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+
+	err := s.db.QueryRow(ctx, query, operation.ID, operation.UserID, operation.Type, operation.Transaction,
+		operation.ExpiresAt).Scan(&operation.CreatedAt)
+	if err != nil {
+		return models.PendingOperation{}, fmt.Errorf("failed to create pending operation: %w", err)
+	}
+
+	return operation, nil
+}
+
+// GetPendingOperation returns the unexpired, unconfirmed operation of the user.
+func (s *Store) GetPendingOperation(ctx context.Context, operationID models.OperationID,
+	userID models.UserID,
+) (models.PendingOperation, error) {
+	var operation models.PendingOperation
+
+	query := `SELECT id, user_id, type, transaction, expires_at, created_at FROM pending_operations
+WHERE id = $1 AND user_id = $2 AND status = 'pending' AND expires_at > NOW()`
+
+	err := s.db.QueryRow(ctx, query, operationID, userID).Scan(
+		&operation.ID,
+		&operation.UserID,
+		&operation.Type,
+		&operation.Transaction,
+		&operation.ExpiresAt,
+		&operation.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PendingOperation{}, models.ErrOperationNotFound
+		}
+
+		return models.PendingOperation{}, fmt.Errorf("failed to get pending operation: %w", err)
+	}
+
+	return operation, nil
+}
+
+// ConfirmPendingOperation marks the operation confirmed, so it runs at most once.
+func (s *Store) ConfirmPendingOperation(ctx context.Context, operationID models.OperationID) error {
+	query := `UPDATE pending_operations SET status = 'confirmed', confirmed_at = NOW()
+WHERE id = $1 AND status = 'pending' AND expires_at > NOW()`
+
+	res, err := s.db.Exec(ctx, query, operationID)
+	if err != nil {
+		return fmt.Errorf("failed to confirm pending operation: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return models.ErrOperationNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) DeletePendingOperations(ctx context.Context, before time.Time) error {
+	if _, err := s.db.Exec(ctx, `DELETE FROM pending_operations WHERE expires_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to delete pending operations: %w", err)
+	}
+
+	return nil
+}
@@ -46,7 +46,8 @@ FROM wallets WHERE id = $1 AND user_id = $2 AND archived = false FOR UPDATE `
 
 func (s *Store) createTxInTable(ctx context.Context, transaction models.Transaction, dbTx pgx.Tx) error {
 	query := `INSERT INTO transactions 
-    (id, name, first_wallet, second_wallet, currency, money) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+    (id, name, first_wallet, second_wallet, currency, money, wallet_currency, wallet_money, rate, mid_rate, fee)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
 
 	args := []any{
 		uuid.New(),
@@ -55,12 +56,21 @@ func (s *Store) createTxInTable(ctx context.Context, transaction models.Transact
 		nil,
 		transaction.Currency,
 		transaction.Money,
+		nil, nil, nil, nil, nil,
 	}
 
 	if transaction.SecondWalletID != nil {
 		args[3] = transaction.SecondWalletID
 	}
 
+	if transaction.Name == models.EventTransfer && transaction.Rate != nil {
+		args[8] = *transaction.Rate
+	}
+
+	if c := transaction.Conversion; c != nil {
+		args[6], args[7], args[8], args[9], args[10] = c.WalletCurrency, c.WalletMoney, c.Rate, c.MidRate, c.Fee
+	}
+
 	err := dbTx.QueryRow(ctx, query, args...).Scan(&transaction.ID)
 	if err != nil {
 		var pgErr *pgconn.PgError
@@ -75,7 +85,8 @@ func (s *Store) createTxInTable(ctx context.Context, transaction models.Transact
 	return nil
 }
 
-func (s *Store) Deposit(ctx context.Context, userID models.UserID, transaction models.Transaction) error {
+// Deposit credits a wallet of the user, or any wallet when userID is nil.
+func (s *Store) Deposit(ctx context.Context, userID *models.UserID, transaction models.Transaction) error {
 	timeStart := time.Now()
 	defer func() {
 		s.metrics.txDuration.WithLabelValues("deposit").Observe(time.Since(timeStart).Seconds())
@@ -88,25 +99,32 @@ func (s *Store) Deposit(ctx context.Context, userID models.UserID, transaction m
 
 	defer func() {
 		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			logrus.Warnf("failed to rollback transaction: %v", err)
+			logrus.WithContext(ctx).Warnf("failed to rollback transaction: %v", err)
 		}
 	}()
 
-	var wallet models.Wallet
+	var currency string
+
+	query := `SELECT currency FROM wallets
+WHERE id = $1 AND ($2::uuid IS NULL OR user_id = $2) AND archived = false FOR UPDATE`
+
+	if err = tx.QueryRow(ctx, query, transaction.FirstWalletID, userID).Scan(&currency); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get wallet: %w", models.ErrWalletNotFound)
+		}
 
-	wallet, err = s.getWalletTx(ctx, transaction.FirstWalletID, userID, tx)
-	if err != nil {
 		return fmt.Errorf("failed to get wallet: %w", err)
 	}
 
-	if wallet.Currency != transaction.Currency {
+	walletCurrency, money := walletAmount(transaction)
+
+	if currency != walletCurrency {
 		return fmt.Errorf("%w", models.ErrWrongCurrency)
 	}
 
-	query := `UPDATE wallets 
-SET balance = balance + $3, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND archived = false`
+	query = `UPDATE wallets SET balance = balance + $2, updated_at = NOW() WHERE id = $1`
 
-	res, err := tx.Exec(ctx, query, transaction.FirstWalletID, userID, transaction.Money)
+	res, err := tx.Exec(ctx, query, transaction.FirstWalletID, money)
 	if err != nil {
 		return fmt.Errorf("failed to update wallet info: %w", err)
 	}
@@ -143,7 +161,7 @@ func (s *Store) WithdrawMoney(ctx context.Context, userID models.UserID, transac
 
 	defer func() {
 		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			logrus.Warnf("failed to rollback transaction: %v", err)
+			logrus.WithContext(ctx).Warnf("failed to rollback transaction: %v", err)
 		}
 	}()
 
@@ -154,17 +172,19 @@ func (s *Store) WithdrawMoney(ctx context.Context, userID models.UserID, transac
 		return fmt.Errorf("failed to get wallet: %w", err)
 	}
 
+	walletCurrency, money := walletAmount(transaction)
+
 	switch {
-	case wallet.Currency != transaction.Currency:
+	case wallet.Currency != walletCurrency:
 		return fmt.Errorf("%w", models.ErrWrongCurrency)
-	case wallet.Balance < transaction.Money:
+	case wallet.Balance < money:
 		return fmt.Errorf("%w", models.ErrInsufficientFunds)
 	}
 
 	query := `UPDATE wallets 
 SET balance = balance - $3, updated_at = NOW() WHERE id = $1 AND user_id = $2 AND archived = false`
 
-	res, err := tx.Exec(ctx, query, transaction.FirstWalletID, userID, transaction.Money)
+	res, err := tx.Exec(ctx, query, transaction.FirstWalletID, userID, money)
 	if err != nil {
 		return fmt.Errorf("failed to update wallet info: %w", err)
 	}
@@ -203,7 +223,7 @@ func (s *Store) Transfer(ctx context.Context, userID models.UserID, transaction
 
 	defer func() {
 		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			logrus.Warnf("failed to rollback transaction: %v", err)
+			logrus.WithContext(ctx).Warnf("failed to rollback transaction: %v", err)
 		}
 	}()
 
@@ -238,7 +258,14 @@ SET balance = balance + ($2::numeric * $3::numeric), updated_at = NOW() WHERE id
 		return fmt.Errorf("nothing changed: %w", err)
 	}
 
+	if transaction.QuoteID != nil {
+		if err = s.useQuoteTx(ctx, *transaction.QuoteID, tx); err != nil {
+			return err
+		}
+	}
+
 	transaction.Name = "transfer"
+	transaction.Rate = &rate
 
 	if err = s.createTxInTable(ctx, transaction, tx); err != nil {
 		return fmt.Errorf("failed to save history of transaction: %w", err)
@@ -252,6 +279,15 @@ SET balance = balance + ($2::numeric * $3::numeric), updated_at = NOW() WHERE id
 	return nil
 }
 
+// walletAmount returns the currency and amount a deposit or withdrawal changes the balance of the wallet by.
+func walletAmount(transaction models.Transaction) (string, float64) {
+	if c := transaction.Conversion; c != nil {
+		return c.WalletCurrency, c.WalletMoney
+	}
+
+	return transaction.Currency, transaction.Money
+}
+
 func currencyBalanceCheck(wallet models.Wallet, transaction models.Transaction) error {
 	switch {
 	case wallet.Currency != transaction.Currency:
@@ -288,7 +324,11 @@ func (s *Store) GetTransactions(ctx context.Context, request models.GetWalletsRe
 	defer rows.Close()
 
 	for rows.Next() {
-		var transaction models.Transaction
+		var (
+			transaction models.Transaction
+			conversion  txConversion
+		)
+
 		if err = rows.Scan(
 			&transaction.ID,
 			&transaction.Name,
@@ -296,11 +336,19 @@ func (s *Store) GetTransactions(ctx context.Context, request models.GetWalletsRe
 			&transaction.SecondWalletID,
 			&transaction.Currency,
 			&transaction.Money,
+			&conversion.walletCurrency,
+			&conversion.walletMoney,
+			&conversion.rate,
+			&conversion.midRate,
+			&conversion.fee,
 			&transaction.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan transactions row: %w", err)
 		}
 
+		transaction.Conversion = conversion.toModel()
+		transaction.Rate = conversion.transferRate()
+
 		transactions = append(transactions, transaction)
 	}
 
@@ -315,6 +363,79 @@ func (s *Store) GetTransactions(ctx context.Context, request models.GetWalletsRe
 	return transactions, nil
 }
 
+// GetTransaction returns a transaction made from the wallet.
+func (s *Store) GetTransaction(ctx context.Context, txID models.TxID,
+	walletID models.WalletID,
+) (models.Transaction, error) {
+	var (
+		transaction models.Transaction
+		conversion  txConversion
+	)
+
+	query := `SELECT id, name, first_wallet, second_wallet, currency, money, wallet_currency, wallet_money, rate, mid_rate,
+       fee, created_at
+FROM transactions WHERE id = $1 AND first_wallet = $2`
+
+	err := s.db.QueryRow(ctx, query, txID, walletID).Scan(
+		&transaction.ID,
+		&transaction.Name,
+		&transaction.FirstWalletID,
+		&transaction.SecondWalletID,
+		&transaction.Currency,
+		&transaction.Money,
+		&conversion.walletCurrency,
+		&conversion.walletMoney,
+		&conversion.rate,
+		&conversion.midRate,
+		&conversion.fee,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Transaction{}, fmt.Errorf("failed to get transaction: %w", models.ErrTransactionNotFound)
+		}
+
+		return models.Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	transaction.Conversion = conversion.toModel()
+	transaction.Rate = conversion.transferRate()
+
+	return transaction, nil
+}
+
+// txConversion scans the conversion columns, which are null for a transaction that wasn't converted.
+type txConversion struct {
+	walletCurrency *string
+	walletMoney    *float64
+	rate           *float64
+	midRate        *float64
+	fee            *float64
+}
+
+// transferRate returns the rate of a transfer, which is stored without the other conversion columns.
+func (c txConversion) transferRate() *float64 {
+	if c.walletCurrency != nil {
+		return nil
+	}
+
+	return c.rate
+}
+
+func (c txConversion) toModel() *models.TxConversion {
+	if c.walletCurrency == nil || c.walletMoney == nil || c.rate == nil || c.midRate == nil || c.fee == nil {
+		return nil
+	}
+
+	return &models.TxConversion{
+		WalletCurrency: *c.walletCurrency,
+		WalletMoney:    *c.walletMoney,
+		Rate:           *c.rate,
+		MidRate:        *c.midRate,
+		Fee:            *c.fee,
+	}
+}
+
 func (s *Store) getTxQuery(request models.GetWalletsRequest, walletID models.WalletID) (string, []any) {
 	var (
 		sb             strings.Builder
@@ -328,7 +449,8 @@ func (s *Store) getTxQuery(request models.GetWalletsRequest, walletID models.Wal
 		}
 	)
 
-	sb.WriteString(`SELECT id, name, first_wallet, second_wallet, currency, money, created_at 
+	sb.WriteString(`SELECT id, name, first_wallet, second_wallet, currency, money, wallet_currency, wallet_money, rate, mid_rate,
+       fee, created_at 
 FROM transactions WHERE `)
 
 	args = append(args, walletID)
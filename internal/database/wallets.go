@@ -91,7 +91,7 @@ func (s *Store) UpdateWallet(ctx context.Context, walletID models.WalletID, user
 
 	defer func() {
 		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			logrus.Warnf("failed to rollback transaction: %v", err)
+			logrus.WithContext(ctx).Warnf("failed to rollback transaction: %v", err)
 		}
 	}()
 
@@ -184,22 +184,38 @@ func (s *Store) DeleteWallet(ctx context.Context, walletID models.WalletID, user
 func (s *Store) GetWallets(ctx context.Context, request models.GetWalletsRequest,
 	userID models.UserID,
 ) ([]models.Wallet, error) {
-	var (
-		wallets []models.Wallet
-		rows    pgx.Rows
-		err     error
-	)
-
 	query, args := s.getWalletsQuery(request, userID)
-	if rows, err = s.db.Query(ctx, query, args...); err != nil {
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallets: %w", err)
+	}
+
+	return scanWallets(rows)
+}
+
+// GetAllWallets returns every wallet of the user in the order they were created, read in one query
+// so that the wallets are consistent with each other.
+func (s *Store) GetAllWallets(ctx context.Context, userID models.UserID) ([]models.Wallet, error) {
+	query := `SELECT id, user_id, name, currency, balance, archived, created_at, updated_at 
+FROM wallets WHERE user_id = $1 AND archived = false ORDER BY created_at, id`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get wallets: %w", err)
 	}
 
+	return scanWallets(rows)
+}
+
+func scanWallets(rows pgx.Rows) ([]models.Wallet, error) {
 	defer rows.Close()
 
+	wallets := []models.Wallet{}
+
 	for rows.Next() {
 		var wallet models.Wallet
-		if err = rows.Scan(
+		if err := rows.Scan(
 			&wallet.WalletID,
 			&wallet.UserID,
 			&wallet.Name,
@@ -214,14 +230,10 @@ func (s *Store) GetWallets(ctx context.Context, request models.GetWalletsRequest
 		wallets = append(wallets, wallet)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to get wallets: %w", err)
 	}
 
-	if len(wallets) == 0 {
-		return []models.Wallet{}, nil
-	}
-
 	return wallets, nil
 }
 
@@ -278,6 +290,41 @@ ILIKE $%d`, len(args)))
 	return sb.String(), args
 }
 
+func (s *Store) GetWalletsSummary(ctx context.Context, userID models.UserID) (models.WalletsSummary, error) {
+	summary := models.WalletsSummary{Balances: map[string]float64{}}
+
+	query := `SELECT currency, count(*), sum(balance) 
+FROM wallets WHERE user_id = $1 AND archived = false GROUP BY currency`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return models.WalletsSummary{}, fmt.Errorf("failed to get wallets summary: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			currency string
+			count    int
+			balance  float64
+		)
+
+		if err = rows.Scan(&currency, &count, &balance); err != nil {
+			return models.WalletsSummary{}, fmt.Errorf("failed to scan wallets summary: %w", err)
+		}
+
+		summary.Count += count
+		summary.Balances[currency] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		return models.WalletsSummary{}, fmt.Errorf("failed to get wallets summary: %w", err)
+	}
+
+	return summary, nil
+}
+
 func (s *Store) GetCurrency(ctx context.Context, walletID models.WalletID) (models.WalletUpdate, error) {
 	var wallet models.WalletUpdate
 
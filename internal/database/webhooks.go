@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/server"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	query := `INSERT INTO webhooks
+    (id, user_id, url, secret, event_types)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, url, secret, event_types, created_at`
+
+	err := s.db.QueryRow(ctx, query, uuid.New(), webhook.UserID, webhook.URL, webhook.Secret,
+		webhook.EventTypes).Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.EventTypes,
+		&webhook.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return models.Webhook{}, models.ErrUserNotFound
+		}
+
+		return models.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// GetWebhooks returns active webhooks of the user, or of all users when userID is nil.
+func (s *Store) GetWebhooks(ctx context.Context, userID *models.UserID) ([]models.Webhook, error) {
+	query := `SELECT id, user_id, url, event_types, created_at
+FROM webhooks WHERE archived = false AND ($1::uuid IS NULL OR user_id = $1) ORDER BY created_at`
+
+	rows, err := s.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+
+	for rows.Next() {
+		var webhook models.Webhook
+		if err = rows.Scan(
+			&webhook.ID,
+			&webhook.UserID,
+			&webhook.URL,
+			&webhook.EventTypes,
+			&webhook.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhooks: %w", err)
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, webhookID models.WebhookID, userID *models.UserID) error {
+	query := `UPDATE webhooks SET archived = true
+WHERE id = $1 AND archived = false AND ($2::uuid IS NULL OR user_id = $2)`
+
+	res, err := s.db.Exec(ctx, query, webhookID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("failed to delete webhook: %w", models.ErrWebhookNotFound)
+	}
+
+	return nil
+}
+
+func (s *Store) GetWebhookDeliveries(ctx context.Context, request models.GetWalletsRequest,
+	webhookID models.WebhookID, userID *models.UserID,
+) ([]models.WebhookDelivery, error) {
+	if request.Limit == 0 {
+		request.Limit = server.DefaultLimit
+	}
+
+	query := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.status, d.attempts, d.response_code,
+       d.last_error, d.next_attempt_at, d.created_at
+FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.webhook_id = $1 AND ($2::uuid IS NULL OR w.user_id = $2)
+ORDER BY d.created_at DESC LIMIT $3 OFFSET $4`
+
+	rows, err := s.db.Query(ctx, query, webhookID, userID, request.Limit, request.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err = rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseCode,
+			&delivery.LastError,
+			&delivery.NextAttempt,
+			&delivery.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook deliveries: %w", err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Store) RedeliverWebhook(ctx context.Context, deliveryID models.DeliveryID, webhookID models.WebhookID,
+	userID *models.UserID,
+) error {
+	query := `UPDATE webhook_deliveries d SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+FROM webhooks w
+WHERE d.id = $1 AND d.webhook_id = $2 AND w.id = d.webhook_id AND w.archived = false
+  AND ($3::uuid IS NULL OR w.user_id = $3)`
+
+	res, err := s.db.Exec(ctx, query, deliveryID, webhookID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("failed to redeliver webhook: %w", models.ErrDeliveryNotFound)
+	}
+
+	return nil
+}
+
+// EnqueueWebhookDeliveries creates a pending delivery for every webhook subscribed to the event
+// and owned by the owner of one of the event wallets, or registered by an admin for all users.
+// An event already enqueued under its key is skipped.
+func (s *Store) EnqueueWebhookDeliveries(ctx context.Context, event models.WebhookEvent) error {
+	walletIDs := make([]uuid.UUID, 0, len(event.WalletIDs))
+	for _, walletID := range event.WalletIDs {
+		walletIDs = append(walletIDs, uuid.UUID(walletID))
+	}
+
+	query := `INSERT INTO webhook_deliveries (id, webhook_id, event_type, payload, event_key)
+SELECT gen_random_uuid(), id, $1, $2, $4 FROM webhooks
+WHERE archived = false AND $1::varchar = ANY(event_types)
+  AND (user_id IS NULL OR user_id IN (SELECT user_id FROM wallets WHERE id = ANY($3)))
+ON CONFLICT (webhook_id, event_key) DO NOTHING`
+
+	if _, err := s.db.Exec(ctx, query, event.Type, event.Payload, walletIDs, event.Key); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimWebhookDeliveries returns due pending deliveries of webhooks that are not deleted and postpones
+// them by lease, so that concurrent senders do not pick the same delivery twice.
+func (s *Store) ClaimWebhookDeliveries(ctx context.Context, limit int,
+	lease time.Duration,
+) ([]models.WebhookDelivery, error) {
+	query := `WITH due AS (
+    UPDATE webhook_deliveries SET next_attempt_at = NOW() + make_interval(secs => $2)
+    WHERE id IN (SELECT id FROM webhook_deliveries
+                 WHERE status = 'pending' AND next_attempt_at <= NOW()
+                   AND webhook_id IN (SELECT id FROM webhooks WHERE archived = false)
+                 ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+    RETURNING id, webhook_id, event_type, payload, status, attempts, next_attempt_at, created_at)
+SELECT due.id, due.webhook_id, due.event_type, due.payload, due.status, due.attempts, due.next_attempt_at,
+       due.created_at, w.url, w.secret
+FROM due JOIN webhooks w ON w.id = due.webhook_id`
+
+	rows, err := s.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		if err = rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttempt,
+			&delivery.CreatedAt,
+			&delivery.URL,
+			&delivery.Secret); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook deliveries: %w", err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *Store) UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries
+SET status = $2, attempts = $3, response_code = $4, last_error = $5, next_attempt_at = $6 WHERE id = $1`
+
+	res, err := s.db.Exec(ctx, query, delivery.ID, delivery.Status, delivery.Attempts, delivery.ResponseCode,
+		delivery.LastError, delivery.NextAttempt)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("failed to update webhook delivery: %w", models.ErrDeliveryNotFound)
+	}
+
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 )
 
 type producer interface {
-	ProduceUsers(key, value string) error
+	ProduceUsers(ctx context.Context, key, value string) error
 }
 
 type Generator struct {
@@ -46,7 +46,7 @@ func (g *Generator) Run(ctx context.Context) error {
 			return fmt.Errorf("failed to marshal users: %w", err)
 		}
 
-		if err := g.producer.ProduceUsers("", string(usersJSON)); err != nil {
+		if err := g.producer.ProduceUsers(ctx, "", string(usersJSON)); err != nil {
 			return fmt.Errorf("failed to produce users: %w", err)
 		}
 
@@ -0,0 +1,186 @@
+package grpcserver
+
+import (
+	"github.com/Memonagi/wallet_project/internal/grpc-server/walletpb"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func parseUUID(id, field string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, status.Errorf(codes.InvalidArgument, "error parsing %s: %v", field, err)
+	}
+
+	return parsed, nil
+}
+
+func toListRequest(list *walletpb.ListRequest) models.GetWalletsRequest {
+	return models.GetWalletsRequest{
+		Sorting:    list.GetSorting(),
+		Descending: list.GetDescending(),
+		Limit:      int(list.GetLimit()),
+		Filter:     list.GetFilter(),
+		Offset:     int(list.GetOffset()),
+	}
+}
+
+func toTransaction(req *walletpb.TransactionRequest) (models.Transaction, error) {
+	firstWalletID, err := parseUUID(req.GetFirstWalletId(), "first wallet id")
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	transaction := models.Transaction{
+		FirstWalletID: models.WalletID(firstWalletID),
+		Money:         req.GetMoney(),
+		Currency:      req.GetCurrency(),
+	}
+
+	if req.SecondWalletId != nil {
+		secondWalletID, err := parseUUID(req.GetSecondWalletId(), "second wallet id")
+		if err != nil {
+			return models.Transaction{}, err
+		}
+
+		walletID := models.WalletID(secondWalletID)
+		transaction.SecondWalletID = &walletID
+	}
+
+	return transaction, nil
+}
+
+func fromWallet(wallet models.Wallet) *walletpb.Wallet {
+	return &walletpb.Wallet{
+		WalletId:  uuid.UUID(wallet.WalletID).String(),
+		UserId:    uuid.UUID(wallet.UserID).String(),
+		Name:      wallet.Name,
+		Currency:  wallet.Currency,
+		Balance:   wallet.Balance,
+		Archived:  wallet.Archived,
+		CreatedAt: timestamppb.New(wallet.CreatedAt),
+		UpdatedAt: timestamppb.New(wallet.UpdatedAt),
+	}
+}
+
+func fromTransaction(transaction models.Transaction) *walletpb.Transaction {
+	tx := &walletpb.Transaction{
+		Id:            uuid.UUID(transaction.ID).String(),
+		Name:          transaction.Name,
+		FirstWalletId: uuid.UUID(transaction.FirstWalletID).String(),
+		Money:         transaction.Money,
+		Currency:      transaction.Currency,
+		CreatedAt:     timestamppb.New(transaction.CreatedAt),
+	}
+
+	if transaction.SecondWalletID != nil {
+		secondWalletID := uuid.UUID(*transaction.SecondWalletID).String()
+		tx.SecondWalletId = &secondWalletID
+	}
+
+	return tx
+}
+
+func fromPendingOperation(operation models.PendingOperation) *walletpb.PendingOperation {
+	return &walletpb.PendingOperation{
+		Id:        uuid.UUID(operation.ID).String(),
+		Type:      operation.Type,
+		ExpiresAt: timestamppb.New(operation.ExpiresAt),
+	}
+}
+
+func fromProfile(profile models.Profile) *walletpb.Profile {
+	user := profile.User
+
+	return &walletpb.Profile{
+		User: &walletpb.User{
+			UserId:           uuid.UUID(user.UserID).String(),
+			UserName:         user.UserName,
+			UserSurname:      user.UserSurname,
+			UserAge:          int32(user.UserAge), //nolint:gosec
+			UserGender:       user.UserGender,
+			UserEmail:        user.UserEmail,
+			Country:          user.Country,
+			EngagementSource: user.EngagementSource,
+			Status:           user.Status,
+			Archived:         user.Archived,
+			CreatedAt:        timestamppb.New(user.CreatedAt),
+			UpdatedAt:        timestamppb.New(user.UpdatedAt),
+		},
+		Wallets: &walletpb.WalletsSummary{
+			Count:    int32(profile.Wallets.Count), //nolint:gosec
+			Balances: profile.Wallets.Balances,
+		},
+	}
+}
+
+func toWebhook(webhook *walletpb.Webhook) (models.Webhook, error) {
+	result := models.Webhook{
+		URL:        webhook.GetUrl(),
+		Secret:     webhook.GetSecret(),
+		EventTypes: webhook.GetEventTypes(),
+	}
+
+	if webhook.UserId != nil {
+		userID, err := parseUUID(webhook.GetUserId(), "user id")
+		if err != nil {
+			return models.Webhook{}, err
+		}
+
+		owner := models.UserID(userID)
+		result.UserID = &owner
+	}
+
+	return result, nil
+}
+
+func fromWebhook(webhook models.Webhook) *walletpb.Webhook {
+	result := &walletpb.Webhook{
+		Id:         uuid.UUID(webhook.ID).String(),
+		Url:        webhook.URL,
+		Secret:     webhook.Secret,
+		EventTypes: webhook.EventTypes,
+		CreatedAt:  timestamppb.New(webhook.CreatedAt),
+	}
+
+	if webhook.UserID != nil {
+		userID := uuid.UUID(*webhook.UserID).String()
+		result.UserId = &userID
+	}
+
+	return result
+}
+
+func fromWebhookDelivery(delivery models.WebhookDelivery) *walletpb.WebhookDelivery {
+	result := &walletpb.WebhookDelivery{
+		Id:            uuid.UUID(delivery.ID).String(),
+		WebhookId:     uuid.UUID(delivery.WebhookID).String(),
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        delivery.Status,
+		Attempts:      int32(delivery.Attempts), //nolint:gosec
+		LastError:     delivery.LastError,
+		NextAttemptAt: timestamppb.New(delivery.NextAttempt),
+		CreatedAt:     timestamppb.New(delivery.CreatedAt),
+	}
+
+	if delivery.ResponseCode != nil {
+		code := int32(*delivery.ResponseCode) //nolint:gosec
+		result.ResponseCode = &code
+	}
+
+	return result
+}
+
+func fromWalletEvent(event models.WalletEvent) *walletpb.WalletEvent {
+	return &walletpb.WalletEvent{
+		Seq:       event.Seq,
+		WalletId:  uuid.UUID(event.WalletID).String(),
+		Type:      event.Type,
+		Payload:   event.Payload,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+}
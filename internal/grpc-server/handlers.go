@@ -0,0 +1,323 @@
+package grpcserver
+
+import (
+	"context"
+
+	"github.com/Memonagi/wallet_project/internal/grpc-server/walletpb"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const transactionsPageSize = 100
+
+func (s *Server) CreateWallet(ctx context.Context, req *walletpb.CreateWalletRequest) (*walletpb.Wallet, error) {
+	userInfo := s.getFromContext(ctx)
+
+	wallet, err := s.service.CreateWallet(ctx, models.Wallet{
+		Name:     req.GetName(),
+		Currency: req.GetCurrency(),
+	}, userInfo.UserID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return fromWallet(wallet), nil
+}
+
+func (s *Server) GetWallet(ctx context.Context, req *walletpb.WalletRequest) (*walletpb.Wallet, error) {
+	walletID, err := parseUUID(req.GetWalletId(), "wallet id")
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := s.getFromContext(ctx)
+
+	wallet, err := s.service.GetWallet(ctx, models.WalletID(walletID), userInfo.UserID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return fromWallet(wallet), nil
+}
+
+func (s *Server) UpdateWallet(ctx context.Context, req *walletpb.UpdateWalletRequest) (*walletpb.Wallet, error) {
+	walletID, err := parseUUID(req.GetWalletId(), "wallet id")
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := s.getFromContext(ctx)
+	name, currency := req.GetName(), req.GetCurrency()
+
+	wallet, err := s.service.UpdateWallet(ctx, models.WalletID(walletID), userInfo.UserID, models.WalletUpdate{
+		Name:     &name,
+		Currency: &currency,
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return fromWallet(wallet), nil
+}
+
+func (s *Server) DeleteWallet(ctx context.Context, req *walletpb.WalletRequest) (*emptypb.Empty, error) {
+	walletID, err := parseUUID(req.GetWalletId(), "wallet id")
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := s.getFromContext(ctx)
+
+	if err = s.service.DeleteWallet(ctx, models.WalletID(walletID), userInfo.UserID); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetWallets(ctx context.Context, req *walletpb.ListRequest) (*walletpb.GetWalletsResponse, error) {
+	userInfo := s.getFromContext(ctx)
+
+	wallets, err := s.service.GetWallets(ctx, toListRequest(req), userInfo.UserID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &walletpb.GetWalletsResponse{Wallets: make([]*walletpb.Wallet, 0, len(wallets))}
+	for _, wallet := range wallets {
+		resp.Wallets = append(resp.Wallets, fromWallet(wallet))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) Deposit(ctx context.Context, req *walletpb.TransactionRequest) (*emptypb.Empty, error) {
+	return s.transaction(ctx, req, func(ctx context.Context, _ models.UserID, transaction models.Transaction) error {
+		return s.service.Deposit(ctx, s.getFromContext(ctx), transaction)
+	})
+}
+
+func (s *Server) Withdraw(ctx context.Context, req *walletpb.TransactionRequest) (*emptypb.Empty, error) {
+	return s.transaction(ctx, req, s.service.WithdrawMoney)
+}
+
+func (s *Server) Transfer(ctx context.Context, req *walletpb.TransactionRequest) (*emptypb.Empty, error) {
+	return s.transaction(ctx, req, s.service.Transfer)
+}
+
+func (s *Server) transaction(ctx context.Context, req *walletpb.TransactionRequest,
+	apply func(ctx context.Context, userID models.UserID, transaction models.Transaction) error,
+) (*emptypb.Empty, error) {
+	transaction, err := toTransaction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := s.getFromContext(ctx)
+
+	if err = apply(ctx, userInfo.UserID, transaction); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ConfirmOperation runs a withdrawal or transfer held for step-up confirmation.
+func (s *Server) ConfirmOperation(ctx context.Context, req *walletpb.ConfirmOperationRequest) (*emptypb.Empty, error) {
+	operationID, err := parseUUID(req.GetOperationId(), "operation id")
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := s.getFromContext(ctx)
+
+	if err = s.service.ConfirmOperation(ctx, userInfo.UserID, models.OperationID(operationID),
+		req.GetCode()); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// GetTransactions streams the history page by page, starting from the requested offset,
+// until the store returns a short page.
+func (s *Server) GetTransactions(req *walletpb.GetTransactionsRequest,
+	stream grpc.ServerStreamingServer[walletpb.Transaction],
+) error {
+	walletID, err := parseUUID(req.GetWalletId(), "wallet id")
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	userInfo := s.getFromContext(ctx)
+
+	request := toListRequest(req.GetList())
+	if request.Limit <= 0 {
+		request.Limit = transactionsPageSize
+	}
+
+	for {
+		transactions, err := s.service.GetTransactions(ctx, request, models.WalletID(walletID), userInfo.UserID)
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+
+		for _, transaction := range transactions {
+			if err = stream.Send(fromTransaction(transaction)); err != nil {
+				return err //nolint:wrapcheck
+			}
+		}
+
+		if len(transactions) < request.Limit {
+			return nil
+		}
+
+		request.Offset += len(transactions)
+	}
+}
+
+func (s *Server) GetProfile(ctx context.Context, _ *emptypb.Empty) (*walletpb.Profile, error) {
+	userInfo := s.getFromContext(ctx)
+
+	profile, err := s.service.GetProfile(ctx, userInfo.UserID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return fromProfile(profile), nil
+}
+
+func (s *Server) CreateWebhook(ctx context.Context, req *walletpb.Webhook) (*walletpb.Webhook, error) {
+	webhook, err := toWebhook(req)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook, err = s.service.CreateWebhook(ctx, s.getFromContext(ctx), webhook)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return fromWebhook(webhook), nil
+}
+
+func (s *Server) GetWebhooks(ctx context.Context, _ *emptypb.Empty) (*walletpb.GetWebhooksResponse, error) {
+	webhooks, err := s.service.GetWebhooks(ctx, s.getFromContext(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &walletpb.GetWebhooksResponse{Webhooks: make([]*walletpb.Webhook, 0, len(webhooks))}
+	for _, webhook := range webhooks {
+		resp.Webhooks = append(resp.Webhooks, fromWebhook(webhook))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) DeleteWebhook(ctx context.Context, req *walletpb.WebhookRequest) (*emptypb.Empty, error) {
+	webhookID, err := parseUUID(req.GetWebhookId(), "webhook id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.service.DeleteWebhook(ctx, s.getFromContext(ctx), models.WebhookID(webhookID)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) GetWebhookDeliveries(ctx context.Context,
+	req *walletpb.GetWebhookDeliveriesRequest,
+) (*walletpb.GetWebhookDeliveriesResponse, error) {
+	webhookID, err := parseUUID(req.GetWebhookId(), "webhook id")
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.service.GetWebhookDeliveries(ctx, s.getFromContext(ctx), toListRequest(req.GetList()),
+		models.WebhookID(webhookID))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &walletpb.GetWebhookDeliveriesResponse{
+		Deliveries: make([]*walletpb.WebhookDelivery, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, fromWebhookDelivery(delivery))
+	}
+
+	return resp, nil
+}
+
+func (s *Server) RedeliverWebhook(ctx context.Context, req *walletpb.RedeliverWebhookRequest) (*emptypb.Empty, error) {
+	webhookID, err := parseUUID(req.GetWebhookId(), "webhook id")
+	if err != nil {
+		return nil, err
+	}
+
+	deliveryID, err := parseUUID(req.GetDeliveryId(), "delivery id")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.service.RedeliverWebhook(ctx, s.getFromContext(ctx), models.WebhookID(webhookID),
+		models.DeliveryID(deliveryID)); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// StreamWalletEvents mirrors the SSE endpoint: events after last_seq are replayed first,
+// then new ones are pushed as they are committed.
+func (s *Server) StreamWalletEvents(req *walletpb.StreamWalletEventsRequest,
+	stream grpc.ServerStreamingServer[walletpb.WalletEvent],
+) error {
+	ctx := stream.Context()
+	userInfo := s.getFromContext(ctx)
+
+	// Subscribe before reading the last sequence so that no event falls in between.
+	wakeup, unsubscribe := s.service.SubscribeWalletEvents(userInfo.UserID)
+	defer unsubscribe()
+
+	lastSeq := req.GetLastSeq()
+
+	if req.LastSeq == nil {
+		seq, err := s.service.GetLastWalletEventSeq(ctx, userInfo.UserID)
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+
+		lastSeq = seq
+	}
+
+	for {
+		events, err := s.service.GetWalletEvents(ctx, userInfo.UserID, lastSeq)
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+
+		for _, event := range events {
+			if err = stream.Send(fromWalletEvent(event)); err != nil {
+				return err //nolint:wrapcheck
+			}
+
+			lastSeq = event.Seq
+		}
+
+		if len(events) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-wakeup:
+		}
+	}
+}
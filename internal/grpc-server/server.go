@@ -0,0 +1,379 @@
+package grpcserver
+
+//go:generate protoc -I ../../api/proto --go_out=walletpb --go_opt=paths=source_relative --go-grpc_out=walletpb --go-grpc_opt=paths=source_relative wallet.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/grpc-server/walletpb"
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/server"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//nolint:interfacebloat
+type service interface {
+	CreateWallet(ctx context.Context, wallet models.Wallet, userID models.UserID) (models.Wallet, error)
+	GetWallet(ctx context.Context, walletID models.WalletID, userID models.UserID) (models.Wallet, error)
+	UpdateWallet(ctx context.Context, walletID models.WalletID, userID models.UserID,
+		wallet models.WalletUpdate) (models.Wallet, error)
+	DeleteWallet(ctx context.Context, walletID models.WalletID, userID models.UserID) error
+	GetWallets(ctx context.Context, request models.GetWalletsRequest, userID models.UserID) ([]models.Wallet, error)
+	Deposit(ctx context.Context, userInfo models.UserInfo, transaction models.Transaction) error
+	WithdrawMoney(ctx context.Context, userID models.UserID, transaction models.Transaction) error
+	Transfer(ctx context.Context, userID models.UserID, transaction models.Transaction) error
+	ConfirmOperation(ctx context.Context, userID models.UserID, operationID models.OperationID, code string) error
+	GetTransactions(ctx context.Context, request models.GetWalletsRequest, walletID models.WalletID,
+		userID models.UserID) ([]models.Transaction, error)
+	GetProfile(ctx context.Context, userID models.UserID) (models.Profile, error)
+	CreateWebhook(ctx context.Context, userInfo models.UserInfo, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, userInfo models.UserInfo) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userInfo models.UserInfo, webhookID models.WebhookID) error
+	GetWebhookDeliveries(ctx context.Context, userInfo models.UserInfo, request models.GetWalletsRequest,
+		webhookID models.WebhookID) ([]models.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, userInfo models.UserInfo, webhookID models.WebhookID,
+		deliveryID models.DeliveryID) error
+	SubscribeWalletEvents(userID models.UserID) (<-chan struct{}, func())
+	GetWalletEvents(ctx context.Context, userID models.UserID, afterSeq int64) ([]models.WalletEvent, error)
+	GetLastWalletEventSeq(ctx context.Context, userID models.UserID) (int64, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (models.UserInfo, error)
+}
+
+type verifier interface {
+	ParseBearer(header string) (*jwtclaims.Claims, error)
+}
+
+type revocations interface {
+	Check(claims *jwtclaims.Claims) error
+}
+
+type limiter interface {
+	Allow(ctx context.Context, scope, key string, limit models.RateLimit) (models.RateLimitResult, error)
+}
+
+type Server struct {
+	walletpb.UnimplementedWalletServiceServer
+	service     service
+	server      *grpc.Server
+	verifier    verifier
+	revocations revocations
+	limiter     limiter
+	rateLimits  server.RateLimits
+	port        int
+}
+
+type Config struct {
+	Port int
+	// RateLimits are the budgets of the REST API, shared with it through the limiter.
+	RateLimits server.RateLimits
+}
+
+type contextKey string
+
+const (
+	ctxKey          contextKey = "ctxKey"
+	gracefulTimeout            = 10 * time.Second
+)
+
+func New(cfg Config, service service, verifier verifier, revocations revocations, limiter limiter) *Server {
+	s := &Server{
+		service:     service,
+		verifier:    verifier,
+		revocations: revocations,
+		limiter:     limiter,
+		rateLimits:  cfg.RateLimits,
+		port:        cfg.Port,
+	}
+
+	s.server = grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(s.unaryLimit(s.limitIP), s.unaryAuth, s.unaryLimit(s.limitUser)),
+		grpc.ChainStreamInterceptor(s.streamLimit(s.limitIP), s.streamAuth, s.streamLimit(s.limitUser)),
+	)
+
+	walletpb.RegisterWalletServiceServer(s.server, s)
+
+	return s
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("error listening on port %d: %w", s.port, err)
+	}
+
+	logrus.Info("starting grpc server on port ", s.port)
+
+	go func() {
+		<-ctx.Done()
+		logrus.Info("shutting down grpc server on port ", s.port)
+
+		stopped := make(chan struct{})
+
+		go func() {
+			s.server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-time.After(gracefulTimeout):
+			logrus.Warn("error graceful shutting down grpc server: timeout")
+			s.server.Stop()
+		}
+	}()
+
+	if err = s.server.Serve(listener); err != nil {
+		return fmt.Errorf("error serving grpc: %w", err)
+	}
+
+	return nil
+}
+
+//nolint:gochecknoglobals
+var methodScopes = map[string][]string{
+	walletpb.WalletService_CreateWallet_FullMethodName:         {models.ScopeWalletsWrite},
+	walletpb.WalletService_GetWallet_FullMethodName:            {models.ScopeWalletsRead},
+	walletpb.WalletService_UpdateWallet_FullMethodName:         {models.ScopeWalletsWrite},
+	walletpb.WalletService_DeleteWallet_FullMethodName:         {models.ScopeWalletsWrite},
+	walletpb.WalletService_GetWallets_FullMethodName:           {models.ScopeWalletsRead},
+	walletpb.WalletService_Deposit_FullMethodName:              {models.ScopeWalletsWrite, models.ScopeWalletsDeposit},
+	walletpb.WalletService_Withdraw_FullMethodName:             {models.ScopeWalletsWrite},
+	walletpb.WalletService_Transfer_FullMethodName:             {models.ScopeWalletsWrite},
+	walletpb.WalletService_ConfirmOperation_FullMethodName:     {models.ScopeWalletsWrite},
+	walletpb.WalletService_GetTransactions_FullMethodName:      {models.ScopeWalletsRead},
+	walletpb.WalletService_GetProfile_FullMethodName:           {models.ScopeWalletsRead},
+	walletpb.WalletService_CreateWebhook_FullMethodName:        {models.ScopeWebhooks},
+	walletpb.WalletService_GetWebhooks_FullMethodName:          {models.ScopeWebhooks},
+	walletpb.WalletService_DeleteWebhook_FullMethodName:        {models.ScopeWebhooks},
+	walletpb.WalletService_GetWebhookDeliveries_FullMethodName: {models.ScopeWebhooks},
+	walletpb.WalletService_RedeliverWebhook_FullMethodName:     {models.ScopeWebhooks},
+	walletpb.WalletService_StreamWalletEvents_FullMethodName:   {models.ScopeWalletsRead},
+}
+
+// readMethods are limited with the read budget of a user, the other methods with the write budget.
+//
+//nolint:gochecknoglobals
+var readMethods = map[string]bool{
+	walletpb.WalletService_GetWallet_FullMethodName:            true,
+	walletpb.WalletService_GetWallets_FullMethodName:           true,
+	walletpb.WalletService_GetTransactions_FullMethodName:      true,
+	walletpb.WalletService_GetProfile_FullMethodName:           true,
+	walletpb.WalletService_GetWebhooks_FullMethodName:          true,
+	walletpb.WalletService_GetWebhookDeliveries_FullMethodName: true,
+	walletpb.WalletService_StreamWalletEvents_FullMethodName:   true,
+}
+
+// authenticate accepts an API key in the x-api-key metadata or a bearer token,
+// and checks the scopes of the method.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var userInfo models.UserInfo
+
+	if values := md.Get("x-api-key"); len(values) > 0 {
+		var err error
+
+		if userInfo, err = s.service.AuthenticateAPIKey(ctx, values[0]); err != nil {
+			return nil, toStatus(ctx, err)
+		}
+	} else {
+		var header string
+		if values = md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+
+		claims, err := s.verifier.ParseBearer(header)
+		if err == nil {
+			err = s.revocations.Check(claims)
+		}
+
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+
+		userInfo = claims.UserInfo()
+	}
+
+	if !userInfo.HasScope(methodScopes[method]...) {
+		return nil, toStatus(ctx, models.ErrInsufficientScope)
+	}
+
+	return context.WithValue(ctx, ctxKey, userInfo), nil
+}
+
+func (s *Server) unaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, err := s.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authStream{ServerStream: stream, ctx: ctx})
+}
+
+func (s *Server) unaryLimit(limit func(ctx context.Context, method string) error) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := limit(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func (s *Server) streamLimit(limit func(ctx context.Context, method string) error) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := limit(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// limitIP throttles calls by client address before they are authenticated.
+func (s *Server) limitIP(ctx context.Context, _ string) error {
+	var ip string
+
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	return s.allow(ctx, "ip", ip, s.rateLimits.IP)
+}
+
+// limitUser throttles authenticated calls by user with the read or the write budget of the method.
+func (s *Server) limitUser(ctx context.Context, method string) error {
+	userInfo := s.getFromContext(ctx)
+
+	key := uuid.UUID(userInfo.UserID).String()
+	if userInfo.APIKeyID != nil {
+		key = "key:" + uuid.UUID(*userInfo.APIKeyID).String()
+	}
+
+	if readMethods[method] {
+		return s.allow(ctx, "read", key, s.rateLimits.Read)
+	}
+
+	return s.allow(ctx, "write", key, s.rateLimits.Write)
+}
+
+func (s *Server) allow(ctx context.Context, scope, key string, limit models.RateLimit) error {
+	res, err := s.limiter.Allow(ctx, scope, key, limit)
+	if err != nil {
+		// Failing open: an unavailable limiter store must not take the API down.
+		logrus.WithContext(ctx).Warnf("error checking rate limit: %v", err)
+
+		return nil
+	}
+
+	if res.Limit == 0 || res.Allowed {
+		return nil
+	}
+
+	if err = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(res.RetryAfter.Seconds())))); err != nil {
+		logrus.WithContext(ctx).Warnf("error setting retry-after header: %v", err)
+	}
+
+	return toStatus(ctx, models.ErrRateLimited)
+}
+
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+func (a *authStream) Context() context.Context {
+	return a.ctx
+}
+
+func (s *Server) getFromContext(ctx context.Context) models.UserInfo {
+	userInfo, _ := ctx.Value(ctxKey).(models.UserInfo)
+
+	return userInfo
+}
+
+//nolint:cyclop
+func getStatusCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, models.ErrWalletNotFound) || errors.Is(err, models.ErrUserNotFound) ||
+		errors.Is(err, models.ErrEmptyID) || errors.Is(err, models.ErrWebhookNotFound) ||
+		errors.Is(err, models.ErrDeliveryNotFound) || errors.Is(err, models.ErrTransactionNotFound) ||
+		errors.Is(err, models.ErrRatesNotFound) || errors.Is(err, models.ErrOperationNotFound):
+		return codes.NotFound
+	case errors.Is(err, models.ErrWrongUserID) || errors.Is(err, models.ErrInsufficientScope) ||
+		errors.Is(err, models.ErrWrongCode):
+		return codes.PermissionDenied
+	case errors.Is(err, models.ErrInvalidToken) || errors.Is(err, models.ErrInvalidSigningMethod) ||
+		errors.Is(err, models.ErrTokenRevoked):
+		return codes.Unauthenticated
+	case errors.Is(err, models.ErrInsufficientFunds) || errors.Is(err, models.ErrConfirmationRequired) ||
+		errors.Is(err, models.ErrTOTPNotEnrolled):
+		return codes.FailedPrecondition
+	case errors.Is(err, models.ErrRateLimited) || errors.Is(err, models.ErrTooManyAttempts):
+		return codes.ResourceExhausted
+	case errors.Is(err, models.ErrRatesUnavailable) || errors.Is(err, models.ErrRatesStale):
+		return codes.Unavailable
+	case errors.Is(err, models.ErrWrongMoney) || errors.Is(err, models.ErrWrongCurrency) ||
+		errors.Is(err, models.ErrEmptyName) || errors.Is(err, models.ErrUserID) ||
+		errors.Is(err, models.ErrWrongURL) || errors.Is(err, models.ErrWrongEventType) ||
+		errors.Is(err, models.ErrInvalidRequest):
+		return codes.InvalidArgument
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// toStatus logs internal errors with the trace of the call and hides them from the client.
+// An operation that requires confirmation is returned in the details, for ConfirmOperation.
+func toStatus(ctx context.Context, err error) error {
+	code := getStatusCode(err)
+	if code == codes.Internal {
+		logrus.WithContext(ctx).Warn(err.Error())
+
+		return status.Error(code, "internal error")
+	}
+
+	var confirmErr *models.ConfirmationRequiredError
+	if errors.As(err, &confirmErr) {
+		st, detailsErr := status.New(code, err.Error()).WithDetails(fromPendingOperation(confirmErr.Operation))
+		if detailsErr == nil {
+			return st.Err() //nolint:wrapcheck
+		}
+	}
+
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type Check func(ctx context.Context) error
+
+type Health struct {
+	mu           sync.RWMutex
+	checks       map[string]Check
+	shuttingDown atomic.Bool
+	timeout      time.Duration
+}
+
+const checkTimeout = 2 * time.Second
+
+func New() *Health {
+	return &Health{
+		checks:  make(map[string]Check),
+		timeout: checkTimeout,
+	}
+}
+
+// Add registers a dependency checked by readiness.
+func (h *Health) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = check
+}
+
+// SetShuttingDown makes readiness fail, so that no new traffic is routed to the instance.
+func (h *Health) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Readiness runs all checks concurrently, each bounded by the check timeout.
+func (h *Health) Readiness(ctx context.Context) models.Readiness {
+	h.mu.RLock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	h.mu.RUnlock()
+
+	sort.Strings(names)
+
+	results := make([]models.CheckResult, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i] = h.run(ctx, name)
+		}()
+	}
+
+	wg.Wait()
+
+	readiness := models.Readiness{
+		Status: models.HealthOK,
+		Checks: make(map[string]models.CheckResult, len(names)),
+	}
+
+	for i, name := range names {
+		readiness.Checks[name] = results[i]
+
+		if results[i].Status != models.HealthOK {
+			readiness.Status = models.HealthUnavailable
+		}
+	}
+
+	if h.shuttingDown.Load() {
+		readiness.Status = models.HealthShuttingDown
+	}
+
+	return readiness
+}
+
+func (h *Health) run(ctx context.Context, name string) models.CheckResult {
+	h.mu.RLock()
+	check := h.checks[name]
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+
+	result := models.CheckResult{
+		Status:   models.HealthOK,
+		Duration: time.Since(start).String(),
+	}
+
+	if err != nil {
+		result.Status = models.HealthUnavailable
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Live answers as long as the process serves HTTP.
+func (h *Health) Live(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, models.Readiness{Status: models.HealthOK})
+}
+
+func (h *Health) Ready(w http.ResponseWriter, r *http.Request) {
+	readiness := h.Readiness(r.Context())
+
+	status := http.StatusOK
+	if readiness.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, readiness)
+}
+
+func writeJSON(w http.ResponseWriter, status int, response any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logrus.Warnf("error encoding response: %v", err)
+	}
+}
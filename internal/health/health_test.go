@@ -0,0 +1,83 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Memonagi/wallet_project/internal/health"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/stretchr/testify/suite"
+)
+
+var errDown = errors.New("connection refused")
+
+type HealthTestSuite struct {
+	suite.Suite
+}
+
+func TestHealthSetupSuite(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
+
+func (s *HealthTestSuite) ready(h *health.Health) (int, models.Readiness) {
+	w := httptest.NewRecorder()
+	h.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var readiness models.Readiness
+
+	err := json.NewDecoder(w.Body).Decode(&readiness)
+	s.Require().NoError(err)
+
+	return w.Code, readiness
+}
+
+func ok(context.Context) error { return nil }
+
+func (s *HealthTestSuite) TestLive() {
+	w := httptest.NewRecorder()
+	health.New().Live(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	s.Require().Equal(http.StatusOK, w.Code)
+}
+
+func (s *HealthTestSuite) TestReady() {
+	h := health.New()
+	h.Add("postgres", ok)
+	h.Add("kafka", ok)
+
+	code, readiness := s.ready(h)
+
+	s.Require().Equal(http.StatusOK, code)
+	s.Require().Equal(models.HealthOK, readiness.Status)
+	s.Require().Len(readiness.Checks, 2)
+	s.Require().Equal(models.HealthOK, readiness.Checks["kafka"].Status)
+}
+
+func (s *HealthTestSuite) TestDependencyDown() {
+	h := health.New()
+	h.Add("postgres", ok)
+	h.Add("xr", func(context.Context) error { return errDown })
+
+	code, readiness := s.ready(h)
+
+	s.Require().Equal(http.StatusServiceUnavailable, code)
+	s.Require().Equal(models.HealthUnavailable, readiness.Status)
+	s.Require().Equal(models.HealthOK, readiness.Checks["postgres"].Status)
+	s.Require().Equal(models.HealthUnavailable, readiness.Checks["xr"].Status)
+	s.Require().Equal(errDown.Error(), readiness.Checks["xr"].Error)
+}
+
+func (s *HealthTestSuite) TestShuttingDown() {
+	h := health.New()
+	h.Add("postgres", ok)
+	h.SetShuttingDown()
+
+	code, readiness := s.ready(h)
+
+	s.Require().Equal(http.StatusServiceUnavailable, code)
+	s.Require().Equal(models.HealthShuttingDown, readiness.Status)
+}
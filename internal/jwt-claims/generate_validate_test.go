@@ -1,8 +1,12 @@
 package jwtclaims_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 	"time"
 
@@ -56,7 +60,7 @@ func (s *JWTTestSuite) TestValidateToken() {
 	require.NotEmpty(s.T(), tokenStr, "tokenStr should not be empty")
 
 	newClaims := &jwtclaims.Claims{}
-	err = newClaims.ValidateToken(tokenStr, s.publicKey)
+	err = newClaims.VerifyToken(tokenStr, s.publicKey)
 	require.NoError(s.T(), err)
 
 	require.Equal(s.T(), claims.UserID, newClaims.UserID)
@@ -78,7 +82,7 @@ func (s *JWTTestSuite) TestValidateInvalidSignature() {
 	require.NotEmpty(s.T(), tokenStr, "tokenStr should not be empty")
 
 	newClaims := &jwtclaims.Claims{}
-	err = newClaims.ValidateToken(tokenStr, randomPublicKey)
+	err = newClaims.VerifyToken(tokenStr, randomPublicKey)
 	require.Error(s.T(), err, "Must return error with invalid signature")
 }
 
@@ -94,7 +98,7 @@ func (s *JWTTestSuite) TestValidateInvalidSignatureMethod() {
 	require.NotEmpty(s.T(), tokenStr, "tokenStr should not be empty")
 
 	newClaims := &jwtclaims.Claims{}
-	err = newClaims.ValidateToken(tokenStr, s.publicKey)
+	err = newClaims.VerifyToken(tokenStr, s.publicKey)
 	require.Error(s.T(), err, "Must return error with invalid signature method")
 }
 
@@ -114,6 +118,59 @@ func (s *JWTTestSuite) TestValidateExpiredToken() {
 	require.NotEmpty(s.T(), tokenStr, "tokenStr should not be empty")
 
 	newClaims := &jwtclaims.Claims{}
-	err = newClaims.ValidateToken(tokenStr, s.publicKey)
+	err = newClaims.VerifyToken(tokenStr, s.publicKey)
 	require.Error(s.T(), err, "Must return error with expired token")
 }
+
+func (s *JWTTestSuite) TestUserInfoScopes() {
+	claims := jwtclaims.New()
+	claims.UserID = models.UserID(uuid.New())
+
+	userInfo := claims.UserInfo()
+	s.Require().Nil(userInfo.Scopes)
+	s.Require().True(userInfo.HasScope(models.ScopeWalletsWrite))
+
+	claims.Scope = "wallets:read webhooks"
+
+	userInfo = claims.UserInfo()
+	s.Require().Equal([]string{models.ScopeWalletsRead, models.ScopeWebhooks}, userInfo.Scopes)
+	s.Require().True(userInfo.HasScope(models.ScopeWalletsWrite, models.ScopeWebhooks))
+	s.Require().False(userInfo.HasScope(models.ScopeWalletsWrite, models.ScopeWalletsDeposit))
+}
+
+func (s *JWTTestSuite) TestVerifyTokenES256() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	s.Require().NoError(err)
+
+	publicKey, err := jwtclaims.ParsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	s.Require().NoError(err)
+
+	claims := jwtclaims.New()
+	claims.UserID = models.UserID(uuid.New())
+
+	tokenStr, err := claims.SignToken(jwtclaims.DefaultKeyID, privateKey)
+	s.Require().NoError(err)
+
+	s.Run("valid ES256 token", func() {
+		newClaims := &jwtclaims.Claims{}
+		s.Require().NoError(newClaims.VerifyToken(tokenStr, publicKey))
+		s.Require().Equal(claims.UserID, newClaims.UserID)
+	})
+
+	s.Run("RS256 token with an EC key", func() {
+		rsaToken, err := claims.GenerateToken(s.privateKey)
+		s.Require().NoError(err)
+
+		s.Require().Error((&jwtclaims.Claims{}).VerifyToken(rsaToken, publicKey))
+	})
+
+	s.Run("RSA key still works", func() {
+		rsaToken, err := claims.GenerateToken(s.privateKey)
+		s.Require().NoError(err)
+
+		s.Require().NoError((&jwtclaims.Claims{}).VerifyToken(rsaToken, s.publicKey))
+	})
+}
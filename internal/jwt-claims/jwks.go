@@ -0,0 +1,318 @@
+package jwtclaims
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultKeyID is the kid of the embedded key pair, used when no JWKS is configured.
+const DefaultKeyID = "wallet-default"
+
+const (
+	defaultRefreshInterval = 5 * time.Minute
+	fetchTimeout           = 10 * time.Second
+)
+
+var (
+	errNoKeys         = errors.New("key set has no signing keys")
+	errUnknownKeyID   = errors.New("unknown key id")
+	errUnsupportedKey = errors.New("unsupported key")
+)
+
+type VerifierConfig struct {
+	// JWKS is a path to a JWKS file or an http(s) URL. The embedded public key is used when empty.
+	JWKS            string
+	RefreshInterval time.Duration
+	Issuer          string
+	Audience        string
+}
+
+// Verifier checks bearer tokens against a key set selected by kid.
+type Verifier struct {
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	source   string
+	refresh  time.Duration
+	issuer   string
+	audience string
+	client   *http.Client
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+func NewVerifier(ctx context.Context, cfg VerifierConfig) (*Verifier, error) {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+
+	v := &Verifier{
+		source:   cfg.JWKS,
+		refresh:  cfg.RefreshInterval,
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		client:   &http.Client{Timeout: fetchTimeout},
+	}
+
+	if v.source == "" {
+		key, err := ReadPublicKey()
+		if err != nil {
+			return nil, err
+		}
+
+		v.keys = map[string]crypto.PublicKey{DefaultKeyID: key}
+
+		return v, nil
+	}
+
+	if err := v.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// Run reloads the key set periodically. The previous keys are kept when a reload fails.
+func (v *Verifier) Run(ctx context.Context) error {
+	if v.source == "" {
+		<-ctx.Done()
+
+		return nil
+	}
+
+	t := time.NewTicker(v.refresh)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := v.Refresh(ctx); err != nil {
+				logrus.Warnf("failed to refresh jwks: %v", err)
+			}
+		}
+	}
+}
+
+func (v *Verifier) Refresh(ctx context.Context) error {
+	data, err := v.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+
+	return nil
+}
+
+func (v *Verifier) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(v.source, "http://") && !strings.HasPrefix(v.source, "https://") {
+		data, err := os.ReadFile(v.source)
+		if err != nil {
+			return nil, fmt.Errorf("error reading jwks file: %w", err)
+		}
+
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating jwks request: %w", err)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching jwks: %w", err)
+	}
+
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			logrus.Warnf("error closing jwks response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading jwks response: %w", err)
+	}
+
+	return data, nil
+}
+
+// ParseBearer validates the "Bearer <token>" authorization header value.
+func (v *Verifier) ParseBearer(header string) (*Claims, error) {
+	tokenStr, found := strings.CutPrefix(header, "Bearer ")
+	if !found || tokenStr == "" {
+		return nil, models.ErrInvalidToken
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+
+	if v.audience != "" {
+		opts = append(opts, jwt.WithAudience(v.audience))
+	}
+
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenStr, claims, v.keyFunc, opts...)
+	if err != nil || !token.Valid {
+		return nil, models.ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := v.keys[kid]
+	if !ok && kid == "" && len(v.keys) == 1 {
+		// Tokens issued before key ids were introduced are accepted while only one key is published.
+		for _, only := range v.keys {
+			key, ok = only, true
+		}
+	}
+
+	if !ok {
+		return nil, errUnknownKeyID
+	}
+
+	if err := checkMethod(token, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func parseKeySet(data []byte) (map[string]crypto.PublicKey, error) {
+	var set jwkSet
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("error decoding jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			logrus.Warnf("skipping jwk %q: %v", k.Kid, err)
+
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errNoKeys
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == jwt.SigningMethodRS256.Alg()):
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("%w: rsa exponent is too large", errUnsupportedKey)
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == jwt.SigningMethodES256.Alg()):
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if _, err = key.ECDH(); err != nil {
+			return nil, fmt.Errorf("%w: %w", errUnsupportedKey, err)
+		}
+
+		return key, nil
+	default:
+		return nil, fmt.Errorf("%w: kty %q, crv %q, alg %q", errUnsupportedKey, k.Kty, k.Crv, k.Alg)
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding jwk parameter: %w", err)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,233 @@
+package jwtclaims_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type JWKSTestSuite struct {
+	suite.Suite
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func (s *JWKSTestSuite) SetupSuite() {
+	var err error
+
+	s.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	s.ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+}
+
+func TestJWKSSetupSuite(t *testing.T) {
+	suite.Run(t, new(JWKSTestSuite))
+}
+
+func (s *JWKSTestSuite) TestEmbeddedKey() {
+	verifier, err := jwtclaims.NewVerifier(context.Background(), jwtclaims.VerifierConfig{})
+	s.Require().NoError(err)
+
+	privateKey, err := jwtclaims.ReadPrivateKey()
+	s.Require().NoError(err)
+
+	claims := s.claims()
+
+	tokenStr, err := claims.GenerateToken(privateKey)
+	s.Require().NoError(err)
+
+	parsed, err := verifier.ParseBearer("Bearer " + tokenStr)
+	s.Require().NoError(err)
+	s.Require().Equal(claims.UserID, parsed.UserID)
+
+	s.Run("token without kid", func() {
+		tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodRS256, s.claims()).SignedString(privateKey)
+		s.Require().NoError(err)
+
+		_, err = verifier.ParseBearer("Bearer " + tokenStr)
+		s.Require().NoError(err)
+	})
+}
+
+func (s *JWKSTestSuite) TestFileKeySet() {
+	path := filepath.Join(s.T().TempDir(), "jwks.json")
+	err := os.WriteFile(path, s.keySet(map[string]any{"rsa-1": &s.rsaKey.PublicKey, "ec-1": &s.ecKey.PublicKey}), 0o600)
+	s.Require().NoError(err)
+
+	verifier, err := jwtclaims.NewVerifier(context.Background(), jwtclaims.VerifierConfig{JWKS: path})
+	s.Require().NoError(err)
+
+	s.Run("rs256", func() {
+		_, err := verifier.ParseBearer(s.sign("rsa-1", s.rsaKey))
+		s.Require().NoError(err)
+	})
+
+	s.Run("es256", func() {
+		_, err := verifier.ParseBearer(s.sign("ec-1", s.ecKey))
+		s.Require().NoError(err)
+	})
+
+	s.Run("unknown kid", func() {
+		_, err := verifier.ParseBearer(s.sign("rsa-2", s.rsaKey))
+		s.Require().ErrorIs(err, models.ErrInvalidToken)
+	})
+
+	s.Run("token without kid", func() {
+		tokenStr, err := jwt.NewWithClaims(jwt.SigningMethodRS256, s.claims()).SignedString(s.rsaKey)
+		s.Require().NoError(err)
+
+		_, err = verifier.ParseBearer("Bearer " + tokenStr)
+		s.Require().ErrorIs(err, models.ErrInvalidToken)
+	})
+
+	s.Run("algorithm does not match key", func() {
+		_, err := verifier.ParseBearer(s.sign("ec-1", s.rsaKey))
+		s.Require().ErrorIs(err, models.ErrInvalidToken)
+	})
+}
+
+func (s *JWKSTestSuite) TestIssuerAndAudience() {
+	path := filepath.Join(s.T().TempDir(), "jwks.json")
+	err := os.WriteFile(path, s.keySet(map[string]any{"rsa-1": &s.rsaKey.PublicKey}), 0o600)
+	s.Require().NoError(err)
+
+	verifier, err := jwtclaims.NewVerifier(context.Background(), jwtclaims.VerifierConfig{
+		JWKS:     path,
+		Issuer:   "https://auth.example.com",
+		Audience: "wallet-service",
+	})
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name     string
+		issuer   string
+		audience []string
+		valid    bool
+	}{
+		{name: "valid", issuer: "https://auth.example.com", audience: []string{"wallet-service"}, valid: true},
+		{name: "one of audiences", issuer: "https://auth.example.com", audience: []string{"other", "wallet-service"},
+			valid: true},
+		{name: "wrong issuer", issuer: "https://evil.example.com", audience: []string{"wallet-service"}},
+		{name: "wrong audience", issuer: "https://auth.example.com", audience: []string{"other"}},
+		{name: "missing claims"},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			claims := s.claims()
+			claims.Issuer = tc.issuer
+			claims.Audience = tc.audience
+
+			tokenStr, err := claims.SignToken("rsa-1", s.rsaKey)
+			s.Require().NoError(err)
+
+			_, err = verifier.ParseBearer("Bearer " + tokenStr)
+			if tc.valid {
+				s.Require().NoError(err)
+			} else {
+				s.Require().ErrorIs(err, models.ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func (s *JWKSTestSuite) TestURLRotation() {
+	var current atomic.Value
+
+	current.Store(s.keySet(map[string]any{"rsa-1": &s.rsaKey.PublicKey}))
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(current.Load().([]byte)) //nolint:forcetypeassert
+	}))
+	defer jwksServer.Close()
+
+	verifier, err := jwtclaims.NewVerifier(context.Background(), jwtclaims.VerifierConfig{JWKS: jwksServer.URL})
+	s.Require().NoError(err)
+
+	_, err = verifier.ParseBearer(s.sign("ec-1", s.ecKey))
+	s.Require().ErrorIs(err, models.ErrInvalidToken)
+
+	current.Store(s.keySet(map[string]any{"ec-1": &s.ecKey.PublicKey}))
+
+	err = verifier.Refresh(context.Background())
+	s.Require().NoError(err)
+
+	_, err = verifier.ParseBearer(s.sign("ec-1", s.ecKey))
+	s.Require().NoError(err)
+
+	_, err = verifier.ParseBearer(s.sign("rsa-1", s.rsaKey))
+	s.Require().ErrorIs(err, models.ErrInvalidToken)
+}
+
+func (s *JWKSTestSuite) TestEmptyKeySet() {
+	path := filepath.Join(s.T().TempDir(), "jwks.json")
+	err := os.WriteFile(path, []byte(`{"keys":[{"kty":"oct","kid":"hmac","k":"c2VjcmV0"}]}`), 0o600)
+	s.Require().NoError(err)
+
+	_, err = jwtclaims.NewVerifier(context.Background(), jwtclaims.VerifierConfig{JWKS: path})
+	s.Require().Error(err)
+}
+
+func (s *JWKSTestSuite) claims() *jwtclaims.Claims {
+	claims := jwtclaims.New()
+	claims.UserID = models.UserID(uuid.New())
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	return claims
+}
+
+func (s *JWKSTestSuite) sign(kid string, key crypto.Signer) string {
+	tokenStr, err := s.claims().SignToken(kid, key)
+	s.Require().NoError(err)
+
+	return "Bearer " + tokenStr
+}
+
+func (s *JWKSTestSuite) keySet(keys map[string]any) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	set := struct {
+		Keys []map[string]string `json:"keys"`
+	}{}
+
+	for kid, key := range keys {
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+				"n": encode(k.N.Bytes()), "e": encode(big.NewInt(int64(k.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{
+				"kty": "EC", "kid": kid, "use": "sig", "alg": "ES256", "crv": "P-256",
+				"x": encode(k.X.FillBytes(make([]byte, 32))), "y": encode(k.Y.FillBytes(make([]byte, 32))),
+			})
+		}
+	}
+
+	data, err := json.Marshal(set)
+	s.Require().NoError(err)
+
+	return data
+}
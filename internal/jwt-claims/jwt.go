@@ -1,19 +1,26 @@
 package jwtclaims
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rsa"
 	_ "embed"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Memonagi/wallet_project/internal/models"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
 	UserID models.UserID `json:"userId"`
 	Email  string        `json:"email"`
 	Role   string        `json:"role"`
+	// Scope is a space-separated list of OAuth scopes; tokens without it have full user access.
+	Scope string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -30,6 +37,7 @@ func New() *Claims {
 
 	return &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(tokenTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -45,6 +53,20 @@ func ReadPrivateKey() (*rsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
+// ParsePrivateKey parses a PEM encoded RSA or EC private key.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+
+	return key, nil
+}
+
 func ReadPublicKey() (*rsa.PublicKey, error) {
 	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyData)
 	if err != nil {
@@ -54,10 +76,50 @@ func ReadPublicKey() (*rsa.PublicKey, error) {
 	return publicKey, nil
 }
 
+// ParsePublicKey parses a PEM encoded RSA or EC public key.
+func ParsePublicKey(data []byte) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+
+	key, err := jwt.ParseECPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key: %w", err)
+	}
+
+	return key, nil
+}
+
 func (c *Claims) GenerateToken(secret *rsa.PrivateKey) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
+	return c.SignToken(DefaultKeyID, secret)
+}
 
-	tokenStr, err := token.SignedString(secret)
+// SignToken signs the claims with RS256 for RSA keys and ES256 for P-256 keys, setting kid in the header.
+// Claims without a jti get a random one, so that the token can be revoked.
+func (c *Claims) SignToken(kid string, key crypto.Signer) (string, error) {
+	var method jwt.SigningMethod
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return "", models.ErrInvalidSigningMethod
+		}
+
+		method = jwt.SigningMethodES256
+	default:
+		return "", models.ErrInvalidSigningMethod
+	}
+
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
+
+	token := jwt.NewWithClaims(method, c)
+	token.Header["kid"] = kid
+
+	tokenStr, err := token.SignedString(key)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -65,13 +127,14 @@ func (c *Claims) GenerateToken(secret *rsa.PrivateKey) (string, error) {
 	return tokenStr, nil
 }
 
-func (c *Claims) ValidateToken(tokenStr string, secret *rsa.PublicKey) error {
+// VerifyToken validates a token signed with RS256 by an RSA key or with ES256 by a P-256 key.
+func (c *Claims) VerifyToken(tokenStr string, key crypto.PublicKey) error {
 	token, err := jwt.ParseWithClaims(tokenStr, c, func(token *jwt.Token) (interface{}, error) {
-		if method, ok := token.Method.(*jwt.SigningMethodRSA); !ok || method != jwt.SigningMethodRS256 {
-			return nil, models.ErrInvalidSigningMethod
+		if err := checkMethod(token, key); err != nil {
+			return nil, err
 		}
 
-		return secret, nil
+		return key, nil
 	})
 	if err != nil {
 		return fmt.Errorf("failed to parse token: %w", err)
@@ -84,11 +147,37 @@ func (c *Claims) ValidateToken(tokenStr string, secret *rsa.PublicKey) error {
 	return nil
 }
 
-func (c *Claims) GetPublicKey() *rsa.PublicKey {
-	key, err := ReadPublicKey()
-	if err != nil {
+// checkMethod makes sure that the token is signed with the method of the key.
+func checkMethod(token *jwt.Token, key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if token.Method != jwt.SigningMethodRS256 {
+			return models.ErrInvalidSigningMethod
+		}
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() || token.Method != jwt.SigningMethodES256 {
+			return models.ErrInvalidSigningMethod
+		}
+	default:
+		return models.ErrInvalidSigningMethod
+	}
+
+	return nil
+}
+
+func (c *Claims) UserInfo() models.UserInfo {
+	return models.UserInfo{
+		UserID: c.UserID,
+		Email:  c.Email,
+		Role:   c.Role,
+		Scopes: scopes(c.Scope),
+	}
+}
+
+func scopes(scope string) []string {
+	if scope == "" {
 		return nil
 	}
 
-	return key
+	return strings.Fields(scope)
 }
@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/json"
 	"errors"
+	"math"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -9,9 +13,45 @@ import (
 )
 
 type (
-	WalletID uuid.UUID
-	UserID   uuid.UUID
-	TxID     uuid.UUID
+	WalletID    uuid.UUID
+	UserID      uuid.UUID
+	TxID        uuid.UUID
+	WebhookID   uuid.UUID
+	DeliveryID  uuid.UUID
+	APIKeyID    uuid.UUID
+	OperationID uuid.UUID
+	QuoteID     uuid.UUID
+	// RateOverrideID identifies a rate set by hand on the XR service.
+	RateOverrideID uuid.UUID
+)
+
+const (
+	EventDeposit        = "deposit"
+	EventWithdraw       = "withdraw"
+	EventTransfer       = "transfer"
+	EventWalletArchived = "wallet_archived"
+
+	RoleAdmin = "admin"
+
+	RateChangeSet    = "set"
+	RateChangeExpire = "expire"
+
+	RoundingHalfEven = "half_even"
+	RoundingFloor    = "floor"
+	RoundingCeil     = "ceil"
+
+	ScopeWalletsRead    = "wallets:read"
+	ScopeWalletsWrite   = "wallets:write"
+	ScopeWalletsDeposit = "wallets:deposit"
+	ScopeWebhooks       = "webhooks"
+	ScopeAdmin          = "admin"
+
+	WalletEventBalance     = "balance"
+	WalletEventTransaction = "transaction"
+
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
 )
 
 type UserExternal struct {
@@ -30,11 +70,59 @@ type UserExternal struct {
 }
 
 type User struct {
-	UserID    UserID    `json:"userId"`
-	Status    string    `json:"status"`
-	Archived  bool      `json:"archived"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	UserID           UserID    `json:"userId"`
+	UserName         string    `json:"userName"`
+	UserSurname      string    `json:"userSurname"`
+	UserAge          int       `json:"userAge"`
+	UserGender       string    `json:"userGender"`
+	UserEmail        string    `json:"userEmail"`
+	Country          string    `json:"country"`
+	EngagementSource string    `json:"engagementSource"`
+	Status           string    `json:"status"`
+	Archived         bool      `json:"archived"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+type WalletsSummary struct {
+	Count    int                `json:"count"`
+	Balances map[string]float64 `json:"balances"`
+}
+
+// NetWorth is the balance of every wallet of a user, and their total converted into Currency at the mid rates
+// and rounded to the decimal places of Currency.
+type NetWorth struct {
+	Currency string           `json:"currency"`
+	Total    float64          `json:"total"`
+	Wallets  []WalletNetWorth `json:"wallets"`
+	Rates    []NetWorthRate   `json:"rates"`
+}
+
+type WalletNetWorth struct {
+	WalletID  WalletID `json:"walletId"`
+	Name      string   `json:"name"`
+	Currency  string   `json:"currency"`
+	Balance   float64  `json:"balance"`
+	Converted float64  `json:"converted"`
+}
+
+// NetWorthRate is a rate the balances were converted at. Date is the date of the rates, FetchedAt is when
+// the XR service published them or when they were fetched from it.
+type NetWorthRate struct {
+	FromCurrency string    `json:"fromCurrency"`
+	ToCurrency   string    `json:"toCurrency"`
+	Rate         float64   `json:"rate"`
+	Date         time.Time `json:"date"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+}
+
+type NetWorthRequest struct {
+	Currency string `json:"currency"`
+}
+
+type Profile struct {
+	User    User           `json:"user"`
+	Wallets WalletsSummary `json:"wallets"`
 }
 
 type Wallet struct {
@@ -61,19 +149,125 @@ type GetWalletsRequest struct {
 	Offset     int    `json:"offset,omitempty"`
 }
 
+// UserInfo is the authenticated caller. Scopes are nil for tokens without a scope claim,
+// which grants full access to the user's own resources.
 type UserInfo struct {
-	UserID UserID `json:"userId"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID   UserID    `json:"userId"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	Scopes   []string  `json:"scopes,omitempty"`
+	APIKeyID *APIKeyID `json:"apiKeyId,omitempty"`
 }
 
+// XRRequest asks for the rate that applied at At, or for the current rate if At is zero.
 type XRRequest struct {
-	FromCurrency string `json:"fromCurrency"`
-	ToCurrency   string `json:"toCurrency"`
+	FromCurrency string    `json:"fromCurrency"`
+	ToCurrency   string    `json:"toCurrency"`
+	At           time.Time `json:"at"`
 }
 
+// XRResponse is a quote of a pair, from the rates of Date. Rate is the mid rate. Converting from
+// the first currency gets Bid, converting to it costs Ask.
 type XRResponse struct {
-	Rate float64 `json:"rate"`
+	Rate     float64   `json:"rate"`
+	Bid      float64   `json:"bid"`
+	Ask      float64   `json:"ask"`
+	Rounding Rounding  `json:"rounding"`
+	Date     time.Time `json:"date"`
+}
+
+// Rounding is how the rates of a quote were rounded to Precision decimal places.
+type Rounding struct {
+	Precision int    `json:"precision"`
+	Mode      string `json:"mode"`
+}
+
+// RateOverride is a rate of a currency pair set by hand. It applies from EffectiveFrom until ExpiresAt
+// in place of the provider rates, and inverted to the opposite pair.
+type RateOverride struct {
+	ID            RateOverrideID `json:"id"`
+	From          string         `json:"from"`
+	To            string         `json:"to"`
+	Bid           float64        `json:"bid"`
+	Ask           float64        `json:"ask"`
+	EffectiveFrom time.Time      `json:"effectiveFrom"`
+	ExpiresAt     *time.Time     `json:"expiresAt,omitempty"`
+}
+
+// RateChange is an audit record of who set or expired a rate override and when.
+type RateChange struct {
+	Action   string       `json:"action"`
+	Override RateOverride `json:"override"`
+	Actor    string       `json:"actor"`
+	At       time.Time    `json:"at"`
+}
+
+// XRConvertRequest asks to convert amounts at the rates of At, or at the current rates if At is zero.
+type XRConvertRequest struct {
+	At          time.Time    `json:"at"`
+	Conversions []Conversion `json:"conversions"`
+}
+
+type XRConvertResponse struct {
+	Date        time.Time    `json:"date"`
+	Conversions []Conversion `json:"conversions"`
+}
+
+// Conversion is an amount to convert. Rate and Result are set by the conversion.
+type Conversion struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+	Rate   float64 `json:"rate"`
+	Result float64 `json:"result"`
+}
+
+// TransactionRate is the exchange rate that applied to a transaction.
+type TransactionRate struct {
+	TransactionID TxID      `json:"transactionId"`
+	FromCurrency  string    `json:"fromCurrency"`
+	ToCurrency    string    `json:"toCurrency"`
+	Rate          float64   `json:"rate"`
+	At            time.Time `json:"at"`
+}
+
+// RatesTo are the units of To per unit of each currency, as published on Date.
+type RatesTo struct {
+	To    string             `json:"to"`
+	Date  time.Time          `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+	// FetchedAt is when the XR service published the rates, or when they were fetched from it.
+	FetchedAt time.Time `json:"-"`
+}
+
+// Rates are the units of each currency per unit of Base, as published on Date.
+type Rates struct {
+	Base  string             `json:"base"`
+	Date  time.Time          `json:"date"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// RateTable has the current quotes of every currency pair, keyed by CurrencyPair. The XR service
+// publishes it on every change of the rates, and periodically so that PublishedAt shows it's current.
+type RateTable struct {
+	Quotes      map[string]XRResponse `json:"quotes"`
+	PublishedAt time.Time             `json:"publishedAt"`
+}
+
+func CurrencyPair(from, to string) string {
+	return from + "/" + to
+}
+
+// RoundAmount rounds the amount to the decimal places of the currency, to cents for an unknown one.
+func RoundAmount(currency string, amount float64) float64 {
+	places, ok := currencies[strings.ToUpper(currency)]
+	if !ok {
+		places = 2
+	}
+
+	scale := math.Pow10(places)
+
+	return math.Round(amount*scale) / scale
 }
 
 type Transaction struct {
@@ -83,7 +277,182 @@ type Transaction struct {
 	SecondWalletID *WalletID `json:"secondWallet"`
 	Money          float64   `json:"money"`
 	Currency       string    `json:"currency"`
-	CreatedAt      time.Time `json:"createdAt"`
+	// QuoteID is the quote whose rate a transfer is converted at, the current rate when nil.
+	QuoteID *QuoteID `json:"quoteId,omitempty"`
+	// Convert lets a deposit or withdrawal be in another currency than the wallet's.
+	Convert bool `json:"convert,omitempty"`
+	// Rate is the rate a transfer was converted at into the currency of the second wallet, set by the store.
+	Rate       *float64      `json:"rate,omitempty"`
+	Conversion *TxConversion `json:"conversion,omitempty"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
+// TxConversion is how a deposit or withdrawal was converted into the currency of the wallet.
+// Rate is the bid of the pair for a deposit and the ask for a withdrawal, MidRate shows the spread.
+// WalletMoney is credited or debited, with the Fee taken off a deposit and added to a withdrawal.
+type TxConversion struct {
+	WalletCurrency string  `json:"walletCurrency"`
+	WalletMoney    float64 `json:"walletMoney"`
+	Rate           float64 `json:"rate"`
+	MidRate        float64 `json:"midRate"`
+	Fee            float64 `json:"fee"`
+}
+
+type Webhook struct {
+	ID         WebhookID `json:"id"`
+	UserID     *UserID   `json:"userId"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"eventTypes"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// APIKey is a service credential. Keys without a user act across users within their scopes.
+// Key is only returned when the key is created; just its hash is stored.
+type APIKey struct {
+	ID        APIKeyID   `json:"id"`
+	Name      string     `json:"name"`
+	UserID    *UserID    `json:"userId"`
+	Scopes    []string   `json:"scopes"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Revocations are the active revoked token ids, and the times before which tokens of a user are revoked.
+type Revocations struct {
+	Tokens map[string]struct{}
+	Users  map[UserID]time.Time
+}
+
+// TokenRevocation revokes a single token, or every token of a user issued before Before.
+type TokenRevocation struct {
+	Token  string    `json:"token,omitempty"`
+	UserID *UserID   `json:"userId,omitempty"`
+	Before time.Time `json:"before,omitempty"`
+}
+
+// TOTP is the second factor of a user. LastStep is the time step of the last accepted code,
+// so a code is never accepted twice.
+type TOTP struct {
+	Secret      string
+	Confirmed   bool
+	LastStep    int64
+	LockedUntil *time.Time
+}
+
+// TOTPEnrollment is returned once when a secret is enrolled, to be added to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URL    string `json:"url"`
+}
+
+type TOTPCode struct {
+	Code string `json:"code"`
+}
+
+// PendingOperation is a withdrawal or transfer above the step-up threshold, kept as requested
+// until it is confirmed with a TOTP code.
+type PendingOperation struct {
+	ID          OperationID `json:"id"`
+	UserID      UserID      `json:"-"`
+	Type        string      `json:"type"`
+	Transaction Transaction `json:"transaction"`
+	ExpiresAt   time.Time   `json:"expiresAt"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
+
+type QuoteRequest struct {
+	FromCurrency string `json:"fromCurrency"`
+	ToCurrency   string `json:"toCurrency"`
+}
+
+// Quote locks the rate of a currency pair for a transfer of the user until ExpiresAt.
+// A quote is used by one transfer only.
+type Quote struct {
+	ID           QuoteID   `json:"id"`
+	UserID       UserID    `json:"-"`
+	FromCurrency string    `json:"fromCurrency"`
+	ToCurrency   string    `json:"toCurrency"`
+	Rate         float64   `json:"rate"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ConfirmationRequiredError carries the pending operation the client has to confirm.
+type ConfirmationRequiredError struct {
+	Operation PendingOperation
+}
+
+func (e *ConfirmationRequiredError) Error() string {
+	return ErrConfirmationRequired.Error() + ": " + uuid.UUID(e.Operation.ID).String()
+}
+
+func (e *ConfirmationRequiredError) Unwrap() error {
+	return ErrConfirmationRequired
+}
+
+// WebhookEvent is enqueued once per Key, which stays the same when the event is consumed again.
+type WebhookEvent struct {
+	Key       string          `json:"key"`
+	Type      string          `json:"type"`
+	WalletIDs []WalletID      `json:"walletIds"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type WebhookDelivery struct {
+	ID           DeliveryID      `json:"id"`
+	WebhookID    WebhookID       `json:"webhookId"`
+	EventType    string          `json:"eventType"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode *int            `json:"responseCode"`
+	LastError    *string         `json:"lastError"`
+	NextAttempt  time.Time       `json:"nextAttemptAt"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	URL          string          `json:"-"`
+	Secret       string          `json:"-"`
+}
+
+type WalletEvent struct {
+	Seq       int64           `json:"seq"`
+	WalletID  WalletID        `json:"walletId"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// RateLimit is a token bucket refilled with Rate tokens per second up to Burst tokens.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+const (
+	HealthOK           = "ok"
+	HealthUnavailable  = "unavailable"
+	HealthShuttingDown = "shutting_down"
+)
+
+type Readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
 }
 
 var (
@@ -98,58 +467,201 @@ var (
 	ErrWrongMoney           = errors.New("zero or negative amount of money")
 	ErrUserID               = errors.New("user ID is empty")
 	ErrWrongUserID          = errors.New("user is not the owner of the wallet")
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrWrongURL             = errors.New("webhook url must be an absolute https url")
+	ErrWrongEventType       = errors.New("event type is invalid")
+	ErrInvalidRequest       = errors.New("invalid request")
+	ErrRateLimited          = errors.New("rate limit exceeded")
+	ErrInsufficientScope    = errors.New("credentials lack the required scope")
+	ErrAdminRequired        = errors.New("admin role required")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrEmptyAPIKeyName      = errors.New("api key name is empty")
+	ErrWrongScope           = errors.New("scope is invalid")
+	ErrWrongExpiry          = errors.New("expiry must be in the future")
+	ErrTokenRevoked         = errors.New("token is revoked")
+	ErrConfirmationRequired = errors.New("operation requires confirmation")
+	ErrOperationNotFound    = errors.New("pending operation not found")
+	ErrTOTPNotEnrolled      = errors.New("totp is not enrolled")
+	ErrTOTPAlreadyEnrolled  = errors.New("totp is already enrolled")
+	ErrWrongCode            = errors.New("verification code is invalid")
+	ErrTooManyAttempts      = errors.New("too many invalid verification codes")
+	ErrRatesUnavailable     = errors.New("exchange rates are unavailable")
+	ErrRatesNotFound        = errors.New("no exchange rates at the time")
+	ErrRatesStale           = errors.New("exchange rates are out of date")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrRateOverrideNotFound = errors.New("rate override not found")
+	ErrQuoteNotFound        = errors.New("quote not found")
+	ErrQuoteExpired         = errors.New("quote has expired")
+	ErrQuoteUsed            = errors.New("quote has already been used")
+	ErrQuoteMismatch        = errors.New("quote is for another currency pair")
+	// currencies are the supported currencies and the decimal places of their amounts.
+	//nolint:gochecknoglobals
+	currencies = map[string]int{
+		"USD": 2,
+		"EUR": 2,
+		"RUB": 2,
+		"JPY": 0,
+		"CNY": 2,
+		"CAD": 2,
+		"AUD": 2,
+	}
 	//nolint:gochecknoglobals
-	currencies = map[string]struct{}{
-		"USD": {},
-		"EUR": {},
-		"RUB": {},
-		"JPY": {},
-		"CNY": {},
-		"CAD": {},
-		"AUD": {},
+	eventTypes = map[string]struct{}{
+		EventDeposit:        {},
+		EventWithdraw:       {},
+		EventTransfer:       {},
+		EventWalletArchived: {},
+	}
+	//nolint:gochecknoglobals
+	scopes = map[string]struct{}{
+		ScopeWalletsRead:    {},
+		ScopeWalletsWrite:   {},
+		ScopeWalletsDeposit: {},
+		ScopeWebhooks:       {},
 	}
 )
 
 func (w *Wallet) Validate() error {
+	var v ValidationError
+
 	if w.Name == "" {
-		return ErrEmptyName
+		v.add("name", ErrEmptyName)
 	}
 
-	_, ok := currencies[strings.ToUpper(w.Currency)]
-	if !ok {
-		return ErrWrongCurrency
+	if _, ok := currencies[strings.ToUpper(w.Currency)]; !ok {
+		v.add("currency", ErrWrongCurrency)
 	}
 
-	return nil
+	return v.orNil()
 }
 
 func (u *WalletUpdate) Validate() error {
-	if *u.Name == "" {
-		return ErrEmptyName
+	var v ValidationError
+
+	if u.Name == nil || *u.Name == "" {
+		v.add("name", ErrEmptyName)
 	}
 
-	_, ok := currencies[strings.ToUpper(*u.Currency)]
-	if !ok {
-		return ErrWrongCurrency
+	if u.Currency == nil {
+		v.add("currency", ErrWrongCurrency)
+	} else if _, ok := currencies[strings.ToUpper(*u.Currency)]; !ok {
+		v.add("currency", ErrWrongCurrency)
 	}
 
-	return nil
+	return v.orNil()
+}
+
+func (n *NetWorthRequest) Validate() error {
+	var v ValidationError
+
+	if _, ok := currencies[strings.ToUpper(n.Currency)]; !ok {
+		v.add("currency", ErrWrongCurrency)
+	}
+
+	return v.orNil()
+}
+
+func (q *QuoteRequest) Validate() error {
+	var v ValidationError
+
+	if _, ok := currencies[strings.ToUpper(q.FromCurrency)]; !ok {
+		v.add("fromCurrency", ErrWrongCurrency)
+	}
+
+	if _, ok := currencies[strings.ToUpper(q.ToCurrency)]; !ok {
+		v.add("toCurrency", ErrWrongCurrency)
+	}
+
+	return v.orNil()
 }
 
 func (t *Transaction) Validate() error {
-	switch {
-	case t.Money == 0:
-		return ErrWrongMoney
-	case t.Money < 0:
-		return ErrWrongMoney
-	case t.FirstWalletID == WalletID(uuid.Nil):
-		return ErrWalletNotFound
+	var v ValidationError
+
+	if t.Money <= 0 {
+		v.add("money", ErrWrongMoney)
 	}
 
-	_, ok := currencies[strings.ToUpper(t.Currency)]
-	if !ok {
-		return ErrWrongCurrency
+	if _, ok := currencies[strings.ToUpper(t.Currency)]; !ok {
+		v.add("currency", ErrWrongCurrency)
+	}
+
+	if err := v.orNil(); err != nil {
+		return err
+	}
+
+	if t.FirstWalletID == WalletID(uuid.Nil) {
+		return ErrWalletNotFound
 	}
 
 	return nil
 }
+
+func (w *Webhook) Validate() error {
+	var v ValidationError
+
+	if u, err := url.Parse(w.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		v.add("url", ErrWrongURL)
+	}
+
+	if len(w.EventTypes) == 0 {
+		v.add("eventTypes", ErrWrongEventType)
+	}
+
+	for _, eventType := range w.EventTypes {
+		if _, ok := eventTypes[eventType]; !ok {
+			v.add("eventTypes", ErrWrongEventType)
+
+			break
+		}
+	}
+
+	return v.orNil()
+}
+
+// HasScope reports whether the caller may use any of the scopes.
+func (u UserInfo) HasScope(scopes ...string) bool {
+	if u.Scopes == nil {
+		return true
+	}
+
+	for _, scope := range scopes {
+		if slices.Contains(u.Scopes, scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsService reports whether the caller is an API key that is not bound to a user.
+func (u UserInfo) IsService() bool {
+	return u.APIKeyID != nil && u.UserID == UserID(uuid.Nil)
+}
+
+func (k *APIKey) Validate() error {
+	var v ValidationError
+
+	if k.Name == "" {
+		v.add("name", ErrEmptyAPIKeyName)
+	}
+
+	if len(k.Scopes) == 0 {
+		v.add("scopes", ErrWrongScope)
+	}
+
+	for _, scope := range k.Scopes {
+		if _, ok := scopes[scope]; !ok {
+			v.add("scopes", ErrWrongScope)
+
+			break
+		}
+	}
+
+	if k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now()) {
+		v.add("expiresAt", ErrWrongExpiry)
+	}
+
+	return v.orNil()
+}
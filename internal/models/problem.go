@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+
+	CodeValidationFailed = "validation_failed"
+	CodeInvalidRequest   = "invalid_request"
+	CodeInternalError    = "internal_error"
+)
+
+// Problem is an RFC 7807 error body. Code is stable across releases and is what clients
+// should match on; Detail is a human-readable message and may change.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	TraceID   string       `json:"traceId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Operation is the pending operation to confirm when Code is confirmation_required.
+	Operation *PendingOperation `json:"operation,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	err     error
+}
+
+// ValidationError reports every invalid field of a request at once.
+// It unwraps to the sentinel errors of the fields, so errors.Is keeps working.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (v *ValidationError) add(field string, err error) {
+	v.Fields = append(v.Fields, FieldError{
+		Field:   field,
+		Code:    ErrorCode(err),
+		Message: err.Error(),
+		err:     err,
+	})
+}
+
+func (v *ValidationError) orNil() error {
+	if len(v.Fields) == 0 {
+		return nil
+	}
+
+	return v
+}
+
+func (v *ValidationError) Error() string {
+	messages := make([]string, 0, len(v.Fields))
+	for _, field := range v.Fields {
+		messages = append(messages, field.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (v *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(v.Fields))
+	for _, field := range v.Fields {
+		errs = append(errs, field.err)
+	}
+
+	return errs
+}
+
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrEmptyName, "wallet_name_empty"},
+	{ErrEmptyID, "wallet_id_empty"},
+	{ErrWalletNotFound, "wallet_not_found"},
+	{ErrUserNotFound, "user_not_found"},
+	{ErrWrongCurrency, "currency_invalid"},
+	{ErrInvalidToken, "token_invalid"},
+	{ErrInvalidSigningMethod, "signing_method_invalid"},
+	{ErrInsufficientFunds, "insufficient_funds"},
+	{ErrWrongMoney, "amount_invalid"},
+	{ErrUserID, "user_id_empty"},
+	{ErrWrongUserID, "not_wallet_owner"},
+	{ErrWebhookNotFound, "webhook_not_found"},
+	{ErrDeliveryNotFound, "webhook_delivery_not_found"},
+	{ErrWrongURL, "webhook_url_invalid"},
+	{ErrWrongEventType, "event_type_invalid"},
+	{ErrInvalidRequest, CodeInvalidRequest},
+	{ErrRateLimited, "rate_limited"},
+	{ErrInsufficientScope, "insufficient_scope"},
+	{ErrAdminRequired, "admin_required"},
+	{ErrAPIKeyNotFound, "api_key_not_found"},
+	{ErrEmptyAPIKeyName, "api_key_name_empty"},
+	{ErrWrongScope, "scope_invalid"},
+	{ErrWrongExpiry, "expiry_invalid"},
+	{ErrTokenRevoked, "token_revoked"},
+	{ErrConfirmationRequired, "confirmation_required"},
+	{ErrOperationNotFound, "operation_not_found"},
+	{ErrTOTPNotEnrolled, "totp_not_enrolled"},
+	{ErrTOTPAlreadyEnrolled, "totp_already_enrolled"},
+	{ErrWrongCode, "code_invalid"},
+	{ErrTooManyAttempts, "too_many_attempts"},
+	{ErrRatesUnavailable, "rates_unavailable"},
+	{ErrRatesNotFound, "rates_not_found"},
+	{ErrRatesStale, "rates_stale"},
+	{ErrTransactionNotFound, "transaction_not_found"},
+	{ErrRateOverrideNotFound, "rate_override_not_found"},
+	{ErrQuoteNotFound, "quote_not_found"},
+	{ErrQuoteExpired, "quote_expired"},
+	{ErrQuoteUsed, "quote_used"},
+	{ErrQuoteMismatch, "quote_mismatch"},
+}
+
+// ErrorCode returns the stable code of the sentinel error wrapped by err,
+// or an empty string if err wraps none of them.
+func ErrorCode(err error) string {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) && len(validationErr.Fields) > 1 {
+		return CodeValidationFailed
+	}
+
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+
+	return ""
+}
+
+// NewProblem builds the problem body for err answered with the given status.
+// Server errors are reported without details, and internal errors without a code either.
+func NewProblem(status int, detail string, err error) Problem {
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   ErrorCode(err),
+	}
+
+	if status >= http.StatusInternalServerError {
+		if status == http.StatusInternalServerError || problem.Code == "" {
+			problem.Code = CodeInternalError
+		}
+
+		return problem
+	}
+
+	problem.Detail = detail
+
+	if problem.Code == "" {
+		problem.Code = CodeInvalidRequest
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Errors = validationErr.Fields
+	}
+
+	var confirmationErr *ConfirmationRequiredError
+	if errors.As(err, &confirmationErr) {
+		problem.Operation = &confirmationErr.Operation
+	}
+
+	return problem
+}
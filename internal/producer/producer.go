@@ -1,9 +1,11 @@
 package producer
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/IBM/sarama"
+	"github.com/Memonagi/wallet_project/internal/tracing"
 )
 
 type Producer struct {
@@ -31,22 +33,35 @@ func (p *Producer) Close() error {
 	return nil
 }
 
-func (p *Producer) produceMessage(topic, key, message string) error {
-	if _, _, err := p.producer.SendMessage(&sarama.ProducerMessage{
+func (p *Producer) produceMessage(ctx context.Context, topic, key, message string) (err error) {
+	msg := &sarama.ProducerMessage{
 		Topic: topic,
 		Key:   sarama.StringEncoder(key),
 		Value: sarama.StringEncoder(message),
-	}); err != nil {
+	}
+
+	_, span := tracing.StartProducerSpan(ctx, msg)
+	defer func() { tracing.End(span, err) }()
+
+	if _, _, err = p.producer.SendMessage(msg); err != nil {
 		return fmt.Errorf("error sending message: %w", err)
 	}
 
 	return nil
 }
 
-func (p *Producer) ProduceUsers(key, value string) error {
-	return p.produceMessage("user_updates", key, value)
+func (p *Producer) ProduceUsers(ctx context.Context, key, value string) error {
+	return p.produceMessage(ctx, "user_updates", key, value)
+}
+
+func (p *Producer) ProduceTx(ctx context.Context, key, value string) error {
+	return p.produceMessage(ctx, "transaction_updates", key, value)
+}
+
+func (p *Producer) ProduceWallet(ctx context.Context, key, value string) error {
+	return p.produceMessage(ctx, "wallet_updates", key, value)
 }
 
-func (p *Producer) ProduceTx(key, value string) error {
-	return p.produceMessage("transaction_updates", key, value)
+func (p *Producer) ProduceRates(ctx context.Context, key, value string) error {
+	return p.produceMessage(ctx, "xr_rates", key, value)
 }
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type store interface {
+	TakeRateLimitToken(ctx context.Context, key string, limit models.RateLimit) (bool, float64, error)
+	DeleteRateLimits(ctx context.Context, before time.Time) error
+}
+
+type Limiter struct {
+	store   store
+	metrics *metrics
+}
+
+const (
+	cleanupInterval = time.Minute
+	idleRetention   = time.Hour
+)
+
+func New(store store) *Limiter {
+	return &Limiter{
+		store:   store,
+		metrics: newMetrics(),
+	}
+}
+
+// Allow takes a token from the bucket of the key. Zero limits are not enforced.
+func (l *Limiter) Allow(ctx context.Context, scope, key string, limit models.RateLimit) (models.RateLimitResult, error) {
+	if limit.Rate <= 0 || limit.Burst <= 0 {
+		return models.RateLimitResult{Allowed: true}, nil
+	}
+
+	allowed, tokens, err := l.store.TakeRateLimitToken(ctx, scope+":"+key, limit)
+	if err != nil {
+		return models.RateLimitResult{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	if !allowed {
+		l.metrics.rejected.WithLabelValues(scope).Inc()
+	}
+
+	return result(limit, allowed, tokens), nil
+}
+
+// Run removes buckets that have not been touched for a while; they are full anyway.
+func (l *Limiter) Run(ctx context.Context) error {
+	t := time.NewTicker(cleanupInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := l.store.DeleteRateLimits(ctx, time.Now().Add(-idleRetention)); err != nil {
+				logrus.Warnf("failed to cleanup rate limits: %v", err)
+			}
+		}
+	}
+}
+
+// refill returns the tokens of the bucket after elapsed time, capped by the burst.
+func refill(tokens float64, elapsed time.Duration, limit models.RateLimit) float64 {
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
+
+func result(limit models.RateLimit, allowed bool, tokens float64) models.RateLimitResult {
+	res := models.RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(math.Max(0, s))) * time.Second
+}
@@ -0,0 +1,86 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/ratelimit"
+	"github.com/stretchr/testify/suite"
+)
+
+type LimiterTestSuite struct {
+	suite.Suite
+	store   *ratelimit.Memory
+	limiter *ratelimit.Limiter
+}
+
+func (s *LimiterTestSuite) SetupSuite() {
+	s.store = ratelimit.NewMemory()
+	s.limiter = ratelimit.New(s.store)
+}
+
+func (s *LimiterTestSuite) SetupTest() {
+	err := s.store.DeleteRateLimits(context.Background(), time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+}
+
+func TestLimiterSetupSuite(t *testing.T) {
+	suite.Run(t, new(LimiterTestSuite))
+}
+
+func (s *LimiterTestSuite) TestBurstExhausted() {
+	limit := models.RateLimit{Rate: 0.1, Burst: 3}
+
+	for i := range limit.Burst {
+		res, err := s.limiter.Allow(context.Background(), "write", "user", limit)
+		s.Require().NoError(err)
+		s.Require().True(res.Allowed)
+		s.Require().Equal(limit.Burst, res.Limit)
+		s.Require().Equal(limit.Burst-i-1, res.Remaining)
+	}
+
+	res, err := s.limiter.Allow(context.Background(), "write", "user", limit)
+	s.Require().NoError(err)
+	s.Require().False(res.Allowed)
+	s.Require().Equal(0, res.Remaining)
+	s.Require().Equal(10*time.Second, res.RetryAfter)
+	s.Require().Equal(30*time.Second, res.Reset)
+}
+
+func (s *LimiterTestSuite) TestKeysAndScopesAreSeparate() {
+	limit := models.RateLimit{Rate: 0.1, Burst: 1}
+
+	for _, key := range [][2]string{{"read", "first"}, {"read", "second"}, {"write", "first"}} {
+		res, err := s.limiter.Allow(context.Background(), key[0], key[1], limit)
+		s.Require().NoError(err)
+		s.Require().True(res.Allowed)
+	}
+
+	res, err := s.limiter.Allow(context.Background(), "read", "first", limit)
+	s.Require().NoError(err)
+	s.Require().False(res.Allowed)
+}
+
+func (s *LimiterTestSuite) TestRefill() {
+	limit := models.RateLimit{Rate: 100, Burst: 1}
+
+	res, err := s.limiter.Allow(context.Background(), "read", "user", limit)
+	s.Require().NoError(err)
+	s.Require().True(res.Allowed)
+
+	time.Sleep(20 * time.Millisecond)
+
+	res, err = s.limiter.Allow(context.Background(), "read", "user", limit)
+	s.Require().NoError(err)
+	s.Require().True(res.Allowed)
+}
+
+func (s *LimiterTestSuite) TestZeroLimitNotEnforced() {
+	for range 10 {
+		res, err := s.limiter.Allow(context.Background(), "ip", "127.0.0.1", models.RateLimit{})
+		s.Require().NoError(err)
+		s.Require().True(res.Allowed)
+	}
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type metrics struct {
+	rejected *prometheus.CounterVec
+}
+
+const (
+	namespace = "wallet_service"
+	subsystem = "ratelimit"
+)
+
+func newMetrics() *metrics {
+	metricList := metrics{
+		rejected: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rejected_total",
+				Help:      "Total number of requests rejected by rate limits.",
+			},
+			[]string{"scope"}),
+	}
+
+	return &metricList
+}
@@ -0,0 +1,166 @@
+package revocation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+type store interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID models.UserID, before, expiresAt time.Time) error
+	GetRevocations(ctx context.Context) (models.Revocations, error)
+	DeleteExpiredRevocations(ctx context.Context) error
+}
+
+// List caches the revocations of the store. Revocations made by other replicas are seen
+// after the next refresh.
+type List struct {
+	store           store
+	mu              sync.RWMutex
+	revocations     models.Revocations
+	refreshInterval time.Duration
+	maxLifetime     time.Duration
+}
+
+type Config struct {
+	RefreshInterval time.Duration
+	// MaxTokenLifetime bounds how long a revocation of all tokens of a user is kept.
+	MaxTokenLifetime time.Duration
+}
+
+const (
+	defaultRefreshInterval = 5 * time.Second
+	defaultMaxLifetime     = 24 * time.Hour
+	cleanupInterval        = time.Hour
+)
+
+func New(store store, cfg Config) *List {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+
+	if cfg.MaxTokenLifetime <= 0 {
+		cfg.MaxTokenLifetime = defaultMaxLifetime
+	}
+
+	return &List{
+		store: store,
+		revocations: models.Revocations{
+			Tokens: make(map[string]struct{}),
+			Users:  make(map[models.UserID]time.Time),
+		},
+		refreshInterval: cfg.RefreshInterval,
+		maxLifetime:     cfg.MaxTokenLifetime,
+	}
+}
+
+// Run refreshes the cache periodically and removes expired revocations from the store.
+func (l *List) Run(ctx context.Context) error {
+	if err := l.Refresh(ctx); err != nil {
+		logrus.Warnf("failed to load token revocations: %v", err)
+	}
+
+	refresh := time.NewTicker(l.refreshInterval)
+	defer refresh.Stop()
+
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-refresh.C:
+			if err := l.Refresh(ctx); err != nil {
+				logrus.Warnf("failed to refresh token revocations: %v", err)
+			}
+		case <-cleanup.C:
+			if err := l.store.DeleteExpiredRevocations(ctx); err != nil {
+				logrus.Warnf("failed to cleanup token revocations: %v", err)
+			}
+		}
+	}
+}
+
+func (l *List) Refresh(ctx context.Context) error {
+	revocations, err := l.store.GetRevocations(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get revocations: %w", err)
+	}
+
+	l.mu.Lock()
+	l.revocations = revocations
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Check returns ErrTokenRevoked if the token or every token of its user issued before it was revoked.
+func (l *List) Check(claims *jwtclaims.Claims) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if _, ok := l.revocations.Tokens[claims.ID]; ok && claims.ID != "" {
+		return models.ErrTokenRevoked
+	}
+
+	before, ok := l.revocations.Users[claims.UserID]
+	if ok && (claims.IssuedAt == nil || claims.IssuedAt.Before(before)) {
+		return models.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// RevokeToken revokes a token of the caller, or of any user for admins, until it expires.
+func (l *List) RevokeToken(ctx context.Context, userInfo models.UserInfo, claims *jwtclaims.Claims) error {
+	if claims.UserID != userInfo.UserID && userInfo.Role != models.RoleAdmin {
+		return models.ErrAdminRequired
+	}
+
+	if claims.ID == "" {
+		return fmt.Errorf("%w: token has no jti", models.ErrInvalidRequest)
+	}
+
+	if err := l.store.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("failed revoke token: %w", err)
+	}
+
+	l.mu.Lock()
+	l.revocations.Tokens[claims.ID] = struct{}{}
+	l.mu.Unlock()
+
+	return nil
+}
+
+// RevokeUserTokens revokes every token of the user issued before the time. Users may revoke
+// their own tokens, admins those of any user.
+func (l *List) RevokeUserTokens(ctx context.Context, userInfo models.UserInfo, userID models.UserID,
+	before time.Time,
+) error {
+	if userID != userInfo.UserID && userInfo.Role != models.RoleAdmin {
+		return models.ErrAdminRequired
+	}
+
+	if before.IsZero() || before.After(time.Now()) {
+		before = time.Now()
+	}
+
+	if err := l.store.RevokeUserTokens(ctx, userID, before, before.Add(l.maxLifetime)); err != nil {
+		return fmt.Errorf("failed revoke user tokens: %w", err)
+	}
+
+	l.mu.Lock()
+	if current, ok := l.revocations.Users[userID]; !ok || current.Before(before) {
+		l.revocations.Users[userID] = before
+	}
+	l.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package revocation_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/revocation"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+	users  map[models.UserID]time.Time
+}
+
+func (f *fakeStore) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.tokens[jti] = expiresAt
+
+	return nil
+}
+
+func (f *fakeStore) RevokeUserTokens(_ context.Context, userID models.UserID, before, _ time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.users[userID] = before
+
+	return nil
+}
+
+func (f *fakeStore) GetRevocations(context.Context) (models.Revocations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	revocations := models.Revocations{
+		Tokens: make(map[string]struct{}),
+		Users:  make(map[models.UserID]time.Time),
+	}
+
+	for jti, expiresAt := range f.tokens {
+		if expiresAt.After(time.Now()) {
+			revocations.Tokens[jti] = struct{}{}
+		}
+	}
+
+	for userID, before := range f.users {
+		revocations.Users[userID] = before
+	}
+
+	return revocations, nil
+}
+
+func (f *fakeStore) DeleteExpiredRevocations(context.Context) error {
+	return nil
+}
+
+type RevocationTestSuite struct {
+	suite.Suite
+	store *fakeStore
+	list  *revocation.List
+	user  models.UserInfo
+}
+
+func (s *RevocationTestSuite) SetupTest() {
+	s.store = &fakeStore{tokens: make(map[string]time.Time), users: make(map[models.UserID]time.Time)}
+	s.list = revocation.New(s.store, revocation.Config{})
+	s.user = models.UserInfo{UserID: models.UserID(uuid.New())}
+}
+
+func TestRevocationSetupSuite(t *testing.T) {
+	suite.Run(t, new(RevocationTestSuite))
+}
+
+func (s *RevocationTestSuite) claims(userID models.UserID, issuedAt time.Time) *jwtclaims.Claims {
+	claims := jwtclaims.New()
+	claims.UserID = userID
+	claims.IssuedAt = jwt.NewNumericDate(issuedAt)
+
+	return claims
+}
+
+func (s *RevocationTestSuite) TestRevokeToken() {
+	revoked := s.claims(s.user.UserID, time.Now())
+	other := s.claims(s.user.UserID, time.Now())
+
+	err := s.list.RevokeToken(context.Background(), s.user, revoked)
+	s.Require().NoError(err)
+
+	s.Require().ErrorIs(s.list.Check(revoked), models.ErrTokenRevoked)
+	s.Require().NoError(s.list.Check(other))
+
+	s.Run("seen by other replicas after refresh", func() {
+		replica := revocation.New(s.store, revocation.Config{})
+		s.Require().NoError(replica.Check(revoked))
+
+		err := replica.Refresh(context.Background())
+		s.Require().NoError(err)
+		s.Require().ErrorIs(replica.Check(revoked), models.ErrTokenRevoked)
+	})
+}
+
+func (s *RevocationTestSuite) TestRevokeUserTokens() {
+	old := s.claims(s.user.UserID, time.Now().Add(-time.Hour))
+	otherUser := s.claims(models.UserID(uuid.New()), time.Now().Add(-time.Hour))
+
+	err := s.list.RevokeUserTokens(context.Background(), s.user, s.user.UserID, time.Now().Add(-time.Minute))
+	s.Require().NoError(err)
+
+	s.Require().ErrorIs(s.list.Check(old), models.ErrTokenRevoked)
+	s.Require().NoError(s.list.Check(s.claims(s.user.UserID, time.Now())))
+	s.Require().NoError(s.list.Check(otherUser))
+}
+
+func (s *RevocationTestSuite) TestOtherUser() {
+	claims := s.claims(models.UserID(uuid.New()), time.Now())
+
+	err := s.list.RevokeToken(context.Background(), s.user, claims)
+	s.Require().ErrorIs(err, models.ErrAdminRequired)
+
+	err = s.list.RevokeUserTokens(context.Background(), s.user, claims.UserID, time.Time{})
+	s.Require().ErrorIs(err, models.ErrAdminRequired)
+
+	admin := models.UserInfo{UserID: models.UserID(uuid.New()), Role: models.RoleAdmin}
+
+	err = s.list.RevokeToken(context.Background(), admin, claims)
+	s.Require().NoError(err)
+	s.Require().ErrorIs(s.list.Check(claims), models.ErrTokenRevoked)
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (s *Server) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var apiKey models.APIKey
+
+	if err := json.NewDecoder(r.Body).Decode(&apiKey); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	newAPIKey, err := s.service.CreateAPIKey(ctx, userInfo, apiKey)
+	if err != nil {
+		s.errorResponse(w, r, "error creating api key", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusCreated, newAPIKey)
+}
+
+func (s *Server) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	apiKeys, err := s.service.GetAPIKeys(ctx, userInfo)
+	if err != nil {
+		s.errorResponse(w, r, "error getting api keys", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, apiKeys)
+}
+
+func (s *Server) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	apiKeyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	if err = s.service.DeleteAPIKey(ctx, userInfo, models.APIKeyID(apiKeyID)); err != nil {
+		s.errorResponse(w, r, "error deleting api key", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, "api key deleted successfully")
+}
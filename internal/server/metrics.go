@@ -45,11 +45,14 @@ func newMetrics() *metrics {
 }
 
 func (m *metrics) trackHTTPRequest(start time.Time, r *http.Request) {
-	id := chi.URLParam(r, "id")
 	url := r.URL.Host + r.URL.Path
 
-	if id != "" {
-		url = strings.Replace(url, id, "{id}", 1)
+	if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil {
+		for i, key := range routeCtx.URLParams.Keys {
+			if value := routeCtx.URLParams.Values[i]; value != "" && key != "*" {
+				url = strings.Replace(url, value, "{"+key+"}", 1)
+			}
+		}
 	}
 
 	timePassed := time.Since(start).Seconds()
@@ -2,13 +2,15 @@ package server
 
 import (
 	"context"
+	"net"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 
-	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
 	"github.com/Memonagi/wallet_project/internal/models"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 type contextKey string
@@ -17,53 +19,32 @@ const (
 	ctxKey contextKey = "ctxKey"
 )
 
-func (s *Server) jwtAuth(next http.Handler) http.Handler {
+// authenticate accepts an API key in the X-API-Key header or a bearer token.
+func (s *Server) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		header := r.Header.Get("Authorization")
-		if header == "" {
-			s.errorResponse(w, "authorization error", models.ErrInvalidToken)
+		var userInfo models.UserInfo
 
-			return
-		}
-
-		headerParts := strings.Split(header, " ")
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			var err error
 
-		if headerParts[0] != "Bearer" {
-			s.errorResponse(w, "authorization error", models.ErrInvalidToken)
-
-			return
-		}
+			if userInfo, err = s.service.AuthenticateAPIKey(r.Context(), key); err != nil {
+				s.errorResponse(w, r, "authorization error", err)
 
-		token, err := jwt.ParseWithClaims(headerParts[1], &jwtclaims.Claims{}, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-				return nil, models.ErrInvalidSigningMethod
+				return
+			}
+		} else {
+			claims, err := s.verifier.ParseBearer(r.Header.Get("Authorization"))
+			if err == nil {
+				err = s.revocations.Check(claims)
 			}
 
-			return s.key, nil
-		})
-		if err != nil {
-			s.errorResponse(w, "authorization error", models.ErrInvalidToken)
-
-			return
-		}
-
-		claims, ok := token.Claims.(*jwtclaims.Claims)
-		if !(ok && token.Valid) {
-			s.errorResponse(w, "authorization error", models.ErrInvalidToken)
-
-			return
-		}
-
-		if claims.ExpiresAt.Before(time.Now()) {
-			s.errorResponse(w, "authorization error", models.ErrInvalidToken)
+			if err != nil {
+				s.errorResponse(w, r, "authorization error", err)
 
-			return
-		}
+				return
+			}
 
-		userInfo := models.UserInfo{
-			UserID: claims.UserID,
-			Email:  claims.Email,
-			Role:   claims.Role,
+			userInfo = claims.UserInfo()
 		}
 
 		r = r.WithContext(context.WithValue(r.Context(), ctxKey, userInfo))
@@ -71,18 +52,111 @@ func (s *Server) jwtAuth(next http.Handler) http.Handler {
 	})
 }
 
+// requireScope rejects callers that have none of the scopes.
+func (s *Server) requireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.getFromContext(r.Context()).HasScope(scopes...) {
+				s.errorResponse(w, r, "authorization error", models.ErrInsufficientScope)
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (s *Server) getFromContext(ctx context.Context) models.UserInfo {
 	userInfo, _ := ctx.Value(ctxKey).(models.UserInfo)
 
 	return userInfo
 }
 
+// metricTrack logs and measures the requests.
 func (s *Server) metricTrack(next http.Handler) http.Handler {
 	var fn http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
-		defer s.metrics.trackHTTPRequest(time.Now(), r)
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
-		next.ServeHTTP(w, r)
+		defer func() {
+			s.metrics.trackHTTPRequest(start, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"status":     status,
+				"duration":   time.Since(start).String(),
+				"request_id": middleware.GetReqID(r.Context()),
+			}).Info("request served")
+		}()
+
+		next.ServeHTTP(ww, r)
 	}
 
 	return fn
 }
+
+// limitIP throttles requests by client address before they are authenticated.
+func (s *Server) limitIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		if s.allow(w, r, "ip", ip, s.rateLimits.IP) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// limitUser throttles authenticated requests by user with the given budget.
+func (s *Server) limitUser(scope string, limit models.RateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userInfo := s.getFromContext(r.Context())
+
+			key := uuid.UUID(userInfo.UserID).String()
+			if userInfo.APIKeyID != nil {
+				key = "key:" + uuid.UUID(*userInfo.APIKeyID).String()
+			}
+
+			if s.allow(w, r, scope, key, limit) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+func (s *Server) allow(w http.ResponseWriter, r *http.Request, scope, key string, limit models.RateLimit) bool {
+	res, err := s.limiter.Allow(r.Context(), scope, key, limit)
+	if err != nil {
+		// Failing open: an unavailable limiter store must not take the API down.
+		logrus.WithContext(r.Context()).Warnf("error checking rate limit: %v", err)
+
+		return true
+	}
+
+	if res.Limit == 0 {
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(res.Reset.Seconds())))
+
+	if res.Allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(res.RetryAfter.Seconds())))
+	s.errorResponse(w, r, "too many requests", models.ErrRateLimited)
+
+	return false
+}
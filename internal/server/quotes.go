@@ -0,0 +1,30 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+func (s *Server) createQuote(w http.ResponseWriter, r *http.Request) {
+	var request models.QuoteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	quote, err := s.service.CreateQuote(ctx, userInfo.UserID, request)
+	if err != nil {
+		s.errorResponse(w, r, "error creating quote", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusCreated, quote)
+}
@@ -2,7 +2,6 @@ package server
 
 import (
 	"context"
-	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,12 +9,16 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/Memonagi/wallet_project/internal/health"
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
 	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/google/uuid"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type service interface {
@@ -25,23 +28,76 @@ type service interface {
 		wallet models.WalletUpdate) (models.Wallet, error)
 	DeleteWallet(ctx context.Context, walletID models.WalletID, userID models.UserID) error
 	GetWallets(ctx context.Context, request models.GetWalletsRequest, userID models.UserID) ([]models.Wallet, error)
-	Deposit(ctx context.Context, userID models.UserID, transaction models.Transaction) error
+	Deposit(ctx context.Context, userInfo models.UserInfo, transaction models.Transaction) error
 	WithdrawMoney(ctx context.Context, userID models.UserID, transaction models.Transaction) error
 	Transfer(ctx context.Context, userID models.UserID, transaction models.Transaction) error
 	GetTransactions(ctx context.Context, request models.GetWalletsRequest, walletID models.WalletID,
 		userID models.UserID) ([]models.Transaction, error)
+	GetTransactionRate(ctx context.Context, userID models.UserID, walletID models.WalletID,
+		txID models.TxID) (models.TransactionRate, error)
+	GetNetWorth(ctx context.Context, userID models.UserID, request models.NetWorthRequest) (models.NetWorth, error)
+	GetProfile(ctx context.Context, userID models.UserID) (models.Profile, error)
+	CreateWebhook(ctx context.Context, userInfo models.UserInfo, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, userInfo models.UserInfo) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userInfo models.UserInfo, webhookID models.WebhookID) error
+	GetWebhookDeliveries(ctx context.Context, userInfo models.UserInfo, request models.GetWalletsRequest,
+		webhookID models.WebhookID) ([]models.WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, userInfo models.UserInfo, webhookID models.WebhookID,
+		deliveryID models.DeliveryID) error
+	SubscribeWalletEvents(userID models.UserID) (<-chan struct{}, func())
+	GetWalletEvents(ctx context.Context, userID models.UserID, afterSeq int64) ([]models.WalletEvent, error)
+	GetLastWalletEventSeq(ctx context.Context, userID models.UserID) (int64, error)
+	CreateAPIKey(ctx context.Context, userInfo models.UserInfo, apiKey models.APIKey) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context, userInfo models.UserInfo) ([]models.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userInfo models.UserInfo, apiKeyID models.APIKeyID) error
+	AuthenticateAPIKey(ctx context.Context, key string) (models.UserInfo, error)
+	EnrollTOTP(ctx context.Context, userID models.UserID) (models.TOTPEnrollment, error)
+	ConfirmTOTP(ctx context.Context, userID models.UserID, code string) error
+	ConfirmOperation(ctx context.Context, userID models.UserID, operationID models.OperationID, code string) error
+	CreateQuote(ctx context.Context, userID models.UserID, request models.QuoteRequest) (models.Quote, error)
+}
+
+type verifier interface {
+	ParseBearer(header string) (*jwtclaims.Claims, error)
+}
+
+type revocations interface {
+	Check(claims *jwtclaims.Claims) error
+	RevokeToken(ctx context.Context, userInfo models.UserInfo, claims *jwtclaims.Claims) error
+	RevokeUserTokens(ctx context.Context, userInfo models.UserInfo, userID models.UserID, before time.Time) error
+}
+
+type limiter interface {
+	Allow(ctx context.Context, scope, key string, limit models.RateLimit) (models.RateLimitResult, error)
 }
 
 type Server struct {
-	service service
-	server  *http.Server
-	key     *rsa.PublicKey
-	port    int
-	metrics *metrics
+	service     service
+	server      *http.Server
+	verifier    verifier
+	revocations revocations
+	port        int
+	metrics     *metrics
+	limiter     limiter
+	rateLimits  RateLimits
+	health      *health.Health
+	drainDelay  time.Duration
 }
 
 type Config struct {
-	Port int
+	Port       int
+	RateLimits RateLimits
+	// DrainDelay is how long the server keeps serving after readiness starts failing on shutdown,
+	// so that load balancers stop routing to it before the listener is closed.
+	DrainDelay time.Duration
+}
+
+// RateLimits are the budgets of a client address, of a user for reads and other requests,
+// and of a user for writes that change wallets. A zero limit is not enforced.
+type RateLimits struct {
+	IP    models.RateLimit
+	Read  models.RateLimit
+	Write models.RateLimit
 }
 
 const (
@@ -50,7 +106,9 @@ const (
 	DefaultLimit      = 25
 )
 
-func New(cfg Config, service service, key *rsa.PublicKey) *Server {
+func New(cfg Config, service service, verifier verifier, revocations revocations, limiter limiter,
+	healthChecks *health.Health,
+) *Server {
 	r := chi.NewRouter()
 
 	s := Server{
@@ -58,41 +116,165 @@ func New(cfg Config, service service, key *rsa.PublicKey) *Server {
 		//nolint:exhaustivestruct
 		server: &http.Server{
 			Addr:              fmt.Sprintf(":%d", cfg.Port),
-			Handler:           r,
+			Handler:           otelhttp.NewHandler(r, "http.server", otelhttp.WithFilter(notProbe)),
 			ReadHeaderTimeout: readHeaderTimeout,
 		},
-		key:     key,
-		port:    cfg.Port,
-		metrics: newMetrics(),
+		verifier:    verifier,
+		revocations: revocations,
+		port:        cfg.Port,
+		metrics:     newMetrics(),
+		limiter:     limiter,
+		rateLimits:  cfg.RateLimits,
+		health:      healthChecks,
+		drainDelay:  cfg.DrainDelay,
 	}
 
+	r.Use(tracing.Route)
+
 	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+	r.Get("/healthz", s.health.Live)
+	r.Get("/readyz", s.health.Ready)
 
 	r.Route("/api/v1/wallets", func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
+		r.Use(s.metricTrack)
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.limitUser("read", s.rateLimits.Read))
+
+			r.With(s.requireScope(models.ScopeWalletsRead)).Get("/summary", s.getNetWorth)
+			r.With(s.requireScope(models.ScopeWalletsRead)).Get("/{id}", s.getWallet)
+			r.With(s.requireScope(models.ScopeWalletsRead)).Get("/", s.getWallets)
+			r.With(s.requireScope(models.ScopeWalletsRead)).Get("/stream", s.streamWalletEvents)
+			r.With(s.requireScope(models.ScopeWalletsRead)).Get("/{id}/transactions", s.getTransactions)
+			r.With(s.requireScope(models.ScopeWalletsRead)).Get("/{id}/transactions/{txId}/rate", s.getTransactionRate)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.limitUser("write", s.rateLimits.Write))
+
+			r.With(s.requireScope(models.ScopeWalletsWrite)).Post("/", s.createWallet)
+			r.With(s.requireScope(models.ScopeWalletsWrite)).Patch("/{id}", s.updateWallet)
+			r.With(s.requireScope(models.ScopeWalletsWrite)).Delete("/{id}", s.deleteWallet)
+			r.With(s.requireScope(models.ScopeWalletsWrite, models.ScopeWalletsDeposit)).Put("/{id}/deposit", s.deposit)
+			r.With(s.requireScope(models.ScopeWalletsWrite)).Put("/{id}/withdraw", s.withdrawMoney)
+			r.With(s.requireScope(models.ScopeWalletsWrite)).Put("/{id}/transfer", s.transfer)
+		})
+	})
+
+	r.Route("/api/v1/me", func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
+		r.Use(s.metricTrack)
+
+		r.With(s.limitUser("read", s.rateLimits.Read), s.requireScope(models.ScopeWalletsRead)).
+			Get("/", s.getProfile)
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.limitUser("write", s.rateLimits.Write))
+			r.Use(s.requireScope(models.ScopeWalletsWrite))
+
+			r.Post("/totp", s.enrollTOTP)
+			r.Post("/totp/confirm", s.confirmTOTP)
+		})
+	})
+
+	r.Route("/api/v1/operations", func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
+		r.Use(s.metricTrack)
+		r.Use(s.limitUser("write", s.rateLimits.Write))
+		r.Use(s.requireScope(models.ScopeWalletsWrite))
+
+		r.Post("/{id}/confirm", s.confirmOperation)
+	})
+
+	r.Route("/api/v1/quotes", func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
+		r.Use(s.metricTrack)
+		r.Use(s.limitUser("write", s.rateLimits.Write))
+		r.Use(s.requireScope(models.ScopeWalletsWrite))
+
+		r.Post("/", s.createQuote)
+	})
+
+	r.Route("/api/v1/webhooks", func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
+		r.Use(s.metricTrack)
+		r.Group(func(r chi.Router) {
+			r.Use(s.limitUser("read", s.rateLimits.Read))
+			r.Use(s.requireScope(models.ScopeWebhooks))
+
+			r.Get("/", s.getWebhooks)
+			r.Get("/{id}/deliveries", s.getWebhookDeliveries)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.limitUser("write", s.rateLimits.Write))
+			r.Use(s.requireScope(models.ScopeWebhooks))
+
+			r.Post("/", s.createWebhook)
+			r.Delete("/{id}", s.deleteWebhook)
+			r.Post("/{id}/deliveries/{deliveryId}/redeliver", s.redeliverWebhook)
+		})
+	})
+
+	r.Route("/api/v1/tokens", func(r chi.Router) {
+		r.Use(middleware.RequestID)
 		r.Use(middleware.Recoverer)
-		r.Use(s.jwtAuth)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
 		r.Use(s.metricTrack)
+		r.Use(s.limitUser("write", s.rateLimits.Write))
+		r.Use(s.requireScope(models.ScopeWalletsWrite, models.ScopeAdmin))
 
-		r.Post("/", s.createWallet)
-		r.Get("/{id}", s.getWallet)
-		r.Patch("/{id}", s.updateWallet)
-		r.Delete("/{id}", s.deleteWallet)
-		r.Get("/", s.getWallets)
-		r.Put("/{id}/deposit", s.deposit)
-		r.Put("/{id}/withdraw", s.withdrawMoney)
-		r.Put("/{id}/transfer", s.transfer)
-		r.Get("/{id}/transactions", s.getTransactions)
+		r.Post("/revoke", s.revokeToken)
+		r.Post("/revoke-all", s.revokeUserTokens)
+	})
+
+	r.Route("/api/v1/admin", func(r chi.Router) {
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.limitIP)
+		r.Use(s.authenticate)
+		r.Use(s.metricTrack)
+		r.Use(s.limitUser("read", s.rateLimits.Read))
+		r.Use(s.requireScope(models.ScopeAdmin))
+
+		r.Post("/api-keys", s.createAPIKey)
+		r.Get("/api-keys", s.getAPIKeys)
+		r.Delete("/api-keys/{id}", s.deleteAPIKey)
 	})
 
 	return &s
 }
 
+// notProbe keeps scrapes and health probes out of traces.
+func notProbe(r *http.Request) bool {
+	return r.URL.Path != "/metrics" && r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
 func (s *Server) Run(ctx context.Context) error {
 	logrus.Info("starting server on port ", s.port)
 
 	go func() {
 		<-ctx.Done()
 		logrus.Info("shutting down server on port ", s.port)
+		s.health.SetShuttingDown()
+		time.Sleep(s.drainDelay)
 
 		gracefulCtx, cancel := context.WithTimeout(context.Background(), gracefulTimeout)
 		defer cancel()
@@ -105,8 +287,8 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}()
 
-	if err := s.server.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
-		logrus.Errorf("error listening on port %d: %v", s.port, err)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error listening on port %d: %w", s.port, err)
 	}
 
 	return nil
@@ -116,46 +298,66 @@ func (s *Server) Run(ctx context.Context) error {
 func getStatusCode(err error) int {
 	switch {
 	case errors.Is(err, models.ErrWalletNotFound) || errors.Is(err, models.ErrUserNotFound) ||
-		errors.Is(err, models.ErrWrongUserID) || errors.Is(err, models.ErrEmptyID):
+		errors.Is(err, models.ErrEmptyID) || errors.Is(err, models.ErrWebhookNotFound) ||
+		errors.Is(err, models.ErrDeliveryNotFound) || errors.Is(err, models.ErrAPIKeyNotFound) ||
+		errors.Is(err, models.ErrOperationNotFound) || errors.Is(err, models.ErrTransactionNotFound) ||
+		errors.Is(err, models.ErrRatesNotFound) || errors.Is(err, models.ErrQuoteNotFound):
 		return http.StatusNotFound
-	case errors.Is(err, models.ErrInvalidToken):
+	case errors.Is(err, models.ErrWrongUserID) || errors.Is(err, models.ErrInsufficientScope) ||
+		errors.Is(err, models.ErrAdminRequired) || errors.Is(err, models.ErrConfirmationRequired) ||
+		errors.Is(err, models.ErrTOTPNotEnrolled) || errors.Is(err, models.ErrWrongCode):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrTOTPAlreadyEnrolled) || errors.Is(err, models.ErrQuoteExpired) ||
+		errors.Is(err, models.ErrQuoteUsed):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrInvalidToken) || errors.Is(err, models.ErrInvalidSigningMethod) ||
+		errors.Is(err, models.ErrTokenRevoked):
 		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrRateLimited) || errors.Is(err, models.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, models.ErrRatesUnavailable) || errors.Is(err, models.ErrRatesStale):
+		return http.StatusServiceUnavailable
 	case errors.Is(err, models.ErrWrongMoney) || errors.Is(err, models.ErrWrongCurrency) ||
-		errors.Is(err, models.ErrInsufficientFunds) || errors.Is(err, models.ErrEmptyName):
+		errors.Is(err, models.ErrInsufficientFunds) || errors.Is(err, models.ErrEmptyName) ||
+		errors.Is(err, models.ErrWrongURL) || errors.Is(err, models.ErrWrongEventType) ||
+		errors.Is(err, models.ErrInvalidRequest) || errors.Is(err, models.ErrEmptyAPIKeyName) ||
+		errors.Is(err, models.ErrWrongScope) || errors.Is(err, models.ErrWrongExpiry) ||
+		errors.Is(err, models.ErrQuoteMismatch):
 		return http.StatusBadRequest
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
-func (s *Server) errorResponse(w http.ResponseWriter, errorText string, err error) {
+func (s *Server) errorResponse(w http.ResponseWriter, r *http.Request, errorText string, err error) {
 	statusCode := getStatusCode(err)
-
-	errResp := fmt.Errorf("%s: %w", errorText, err).Error()
 	if statusCode == http.StatusInternalServerError {
-		errResp = http.StatusText(http.StatusInternalServerError)
-
-		logrus.Warn(err.Error())
+		logrus.WithContext(r.Context()).Warn(err.Error())
 	}
 
-	response, err := json.Marshal(errResp)
-	if err != nil {
-		logrus.Warnf("error marshalling response: %v", err)
-	}
+	problem := models.NewProblem(statusCode, fmt.Errorf("%s: %w", errorText, err).Error(), err)
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+	problem.TraceID = tracing.TraceID(r.Context())
 
+	w.Header().Set("Content-Type", models.ProblemContentType)
 	w.WriteHeader(statusCode)
 
-	if _, err = w.Write(response); err != nil {
-		logrus.Warnf("error writing response: %v", err)
+	if err = json.NewEncoder(w).Encode(problem); err != nil {
+		logrus.WithContext(r.Context()).Warnf("error encoding response: %v", err)
 	}
 }
 
-func (s *Server) okResponse(w http.ResponseWriter, status int, response any) {
+func invalidRequest(err error) error {
+	return fmt.Errorf("%w: %w", models.ErrInvalidRequest, err)
+}
+
+func (s *Server) okResponse(w http.ResponseWriter, r *http.Request, status int, response any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logrus.Warnf("error encoding response: %v", err)
+		logrus.WithContext(r.Context()).Warnf("error encoding response: %v", err)
 	}
 }
 
@@ -166,7 +368,7 @@ func (s *Server) createWallet(w http.ResponseWriter, r *http.Request) {
 	)
 
 	if err = json.NewDecoder(r.Body).Decode(&wallet); err != nil {
-		s.errorResponse(w, "error decoding request body", err)
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
 
 		return
 	}
@@ -175,12 +377,12 @@ func (s *Server) createWallet(w http.ResponseWriter, r *http.Request) {
 	userInfo := s.getFromContext(ctx)
 
 	if newWallet, err = s.service.CreateWallet(ctx, wallet, userInfo.UserID); err != nil {
-		s.errorResponse(w, "error creating wallet", err)
+		s.errorResponse(w, r, "error creating wallet", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusCreated, newWallet)
+	s.okResponse(w, r, http.StatusCreated, newWallet)
 }
 
 func (s *Server) getWallet(w http.ResponseWriter, r *http.Request) {
@@ -188,7 +390,7 @@ func (s *Server) getWallet(w http.ResponseWriter, r *http.Request) {
 
 	walletID, err := uuid.Parse(id)
 	if err != nil {
-		s.errorResponse(w, "error parsing uuid", err)
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
 
 		return
 	}
@@ -198,12 +400,12 @@ func (s *Server) getWallet(w http.ResponseWriter, r *http.Request) {
 
 	walletInfo, err := s.service.GetWallet(ctx, models.WalletID(walletID), userInfo.UserID)
 	if err != nil {
-		s.errorResponse(w, "error reading wallet", err)
+		s.errorResponse(w, r, "error reading wallet", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, walletInfo)
+	s.okResponse(w, r, http.StatusOK, walletInfo)
 }
 
 func (s *Server) updateWallet(w http.ResponseWriter, r *http.Request) {
@@ -211,7 +413,7 @@ func (s *Server) updateWallet(w http.ResponseWriter, r *http.Request) {
 
 	walletID, err := uuid.Parse(id)
 	if err != nil {
-		s.errorResponse(w, "error parsing uuid", err)
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
 
 		return
 	}
@@ -222,19 +424,19 @@ func (s *Server) updateWallet(w http.ResponseWriter, r *http.Request) {
 	var wallet models.WalletUpdate
 
 	if err = json.NewDecoder(r.Body).Decode(&wallet); err != nil {
-		s.errorResponse(w, "error decoding request body", err)
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
 
 		return
 	}
 
 	updatedWallet, err := s.service.UpdateWallet(ctx, models.WalletID(walletID), userInfo.UserID, wallet)
 	if err != nil {
-		s.errorResponse(w, "error updating wallet", err)
+		s.errorResponse(w, r, "error updating wallet", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, updatedWallet)
+	s.okResponse(w, r, http.StatusOK, updatedWallet)
 }
 
 func (s *Server) deleteWallet(w http.ResponseWriter, r *http.Request) {
@@ -242,7 +444,7 @@ func (s *Server) deleteWallet(w http.ResponseWriter, r *http.Request) {
 
 	walletID, err := uuid.Parse(id)
 	if err != nil {
-		s.errorResponse(w, "error parsing uuid", err)
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
 
 		return
 	}
@@ -251,12 +453,12 @@ func (s *Server) deleteWallet(w http.ResponseWriter, r *http.Request) {
 	userInfo := s.getFromContext(ctx)
 
 	if err = s.service.DeleteWallet(ctx, models.WalletID(walletID), userInfo.UserID); err != nil {
-		s.errorResponse(w, "error deleting wallet", err)
+		s.errorResponse(w, r, "error deleting wallet", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, "wallet deleted successfully")
+	s.okResponse(w, r, http.StatusOK, "wallet deleted successfully")
 }
 
 func (s *Server) getWallets(w http.ResponseWriter, r *http.Request) {
@@ -266,12 +468,27 @@ func (s *Server) getWallets(w http.ResponseWriter, r *http.Request) {
 
 	wallets, err := s.service.GetWallets(ctx, request, userInfo.UserID)
 	if err != nil {
-		s.errorResponse(w, "error getting wallets", err)
+		s.errorResponse(w, r, "error getting wallets", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, wallets)
+}
+
+func (s *Server) getNetWorth(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+	request := models.NetWorthRequest{Currency: r.URL.Query().Get("currency")}
+
+	netWorth, err := s.service.GetNetWorth(ctx, userInfo.UserID, request)
+	if err != nil {
+		s.errorResponse(w, r, "error getting net worth", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, wallets)
+	s.okResponse(w, r, http.StatusOK, netWorth)
 }
 
 func parseGetRequest(r *http.Request) models.GetWalletsRequest {
@@ -314,18 +531,21 @@ func (s *Server) deposit(w http.ResponseWriter, r *http.Request) {
 	userInfo := s.getFromContext(ctx)
 
 	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		s.errorResponse(w, "error decoding request body", err)
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
 
 		return
 	}
 
-	if err := s.service.Deposit(ctx, userInfo.UserID, transaction); err != nil {
-		s.errorResponse(w, "deposit transaction failed", err)
+	// the rate is the one the service applies, never the client's
+	transaction.Rate = nil
+
+	if err := s.service.Deposit(ctx, userInfo, transaction); err != nil {
+		s.errorResponse(w, r, "deposit transaction failed", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, "successful transaction")
+	s.okResponse(w, r, http.StatusOK, "successful transaction")
 }
 
 func (s *Server) withdrawMoney(w http.ResponseWriter, r *http.Request) {
@@ -335,18 +555,21 @@ func (s *Server) withdrawMoney(w http.ResponseWriter, r *http.Request) {
 	userInfo := s.getFromContext(ctx)
 
 	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		s.errorResponse(w, "error decoding request body", err)
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
 
 		return
 	}
 
+	// the rate is the one the service applies, never the client's
+	transaction.Rate = nil
+
 	if err := s.service.WithdrawMoney(ctx, userInfo.UserID, transaction); err != nil {
-		s.errorResponse(w, "withdraw transaction failed", err)
+		s.errorResponse(w, r, "withdraw transaction failed", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, "successful transaction")
+	s.okResponse(w, r, http.StatusOK, "successful transaction")
 }
 
 func (s *Server) transfer(w http.ResponseWriter, r *http.Request) {
@@ -356,18 +579,21 @@ func (s *Server) transfer(w http.ResponseWriter, r *http.Request) {
 	userInfo := s.getFromContext(ctx)
 
 	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		s.errorResponse(w, "error decoding request body", err)
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
 
 		return
 	}
 
+	// the rate is the one the service applies, never the client's
+	transaction.Rate = nil
+
 	if err := s.service.Transfer(ctx, userInfo.UserID, transaction); err != nil {
-		s.errorResponse(w, "transfer transaction failed", err)
+		s.errorResponse(w, r, "transfer transaction failed", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, "successful transaction")
+	s.okResponse(w, r, http.StatusOK, "successful transaction")
 }
 
 func (s *Server) getTransactions(w http.ResponseWriter, r *http.Request) {
@@ -377,7 +603,7 @@ func (s *Server) getTransactions(w http.ResponseWriter, r *http.Request) {
 
 	walletID, err := uuid.Parse(id)
 	if err != nil {
-		s.errorResponse(w, "error parsing uuid", err)
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
 
 		return
 	}
@@ -386,10 +612,53 @@ func (s *Server) getTransactions(w http.ResponseWriter, r *http.Request) {
 
 	transactions, err := s.service.GetTransactions(ctx, request, models.WalletID(walletID), userInfo.UserID)
 	if err != nil {
-		s.errorResponse(w, "error getting transactions", err)
+		s.errorResponse(w, r, "error getting transactions", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, transactions)
+}
+
+func (s *Server) getTransactionRate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	walletID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	txID, err := uuid.Parse(chi.URLParam(r, "txId"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	userInfo := s.getFromContext(ctx)
+
+	rate, err := s.service.GetTransactionRate(ctx, userInfo.UserID, models.WalletID(walletID), models.TxID(txID))
+	if err != nil {
+		s.errorResponse(w, r, "error getting transaction rate", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, rate)
+}
+
+func (s *Server) getProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	profile, err := s.service.GetProfile(ctx, userInfo.UserID)
+	if err != nil {
+		s.errorResponse(w, r, "error getting profile", err)
 
 		return
 	}
 
-	s.okResponse(w, http.StatusOK, transactions)
+	s.okResponse(w, r, http.StatusOK, profile)
 }
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (s *Server) enrollTOTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	enrollment, err := s.service.EnrollTOTP(ctx, userInfo.UserID)
+	if err != nil {
+		s.errorResponse(w, r, "error enrolling totp", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusCreated, enrollment)
+}
+
+func (s *Server) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	var code models.TOTPCode
+
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	if err := s.service.ConfirmTOTP(ctx, userInfo.UserID, code.Code); err != nil {
+		s.errorResponse(w, r, "error confirming totp", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, "totp enrolled successfully")
+}
+
+func (s *Server) confirmOperation(w http.ResponseWriter, r *http.Request) {
+	var code models.TOTPCode
+
+	operationID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&code); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	if err = s.service.ConfirmOperation(ctx, userInfo.UserID, models.OperationID(operationID), code.Code); err != nil {
+		s.errorResponse(w, r, "confirmed transaction failed", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, "successful transaction")
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+var errStreamingUnsupported = errors.New("streaming unsupported")
+
+// streamWalletEvents pushes balance changes and new transactions of the caller's wallets
+// as Server-Sent Events. The event id is the event sequence, which only grows in the order
+// events are committed, so a client reconnecting with Last-Event-ID receives everything it has missed.
+//
+//nolint:cyclop
+func (s *Server) streamWalletEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.errorResponse(w, r, "error streaming wallet events", errStreamingUnsupported)
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	// Subscribe before reading the last sequence so that no event falls in between.
+	wakeup, unsubscribe := s.service.SubscribeWalletEvents(userInfo.UserID)
+	defer unsubscribe()
+
+	lastSeq, err := s.lastEventSeq(r, userInfo.UserID)
+	if err != nil {
+		s.errorResponse(w, r, "error streaming wallet events", err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		events, err := s.service.GetWalletEvents(ctx, userInfo.UserID, lastSeq)
+		if err != nil {
+			logrus.WithContext(ctx).Warnf("error getting wallet events: %v", err)
+
+			return
+		}
+
+		for _, event := range events {
+			if err = writeEvent(w, event); err != nil {
+				return
+			}
+
+			lastSeq = event.Seq
+		}
+
+		flusher.Flush()
+
+		if len(events) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-wakeup:
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) lastEventSeq(r *http.Request, userID models.UserID) (int64, error) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	if lastEventID != "" {
+		seq, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("error parsing last event id: %w", invalidRequest(err))
+		}
+
+		return seq, nil
+	}
+
+	seq, err := s.service.GetLastWalletEventSeq(r.Context(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting last event: %w", err)
+	}
+
+	return seq, nil
+}
+
+func writeEvent(w http.ResponseWriter, event models.WalletEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling wallet event: %w", err)
+	}
+
+	if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data); err != nil {
+		return fmt.Errorf("error writing wallet event: %w", err)
+	}
+
+	return nil
+}
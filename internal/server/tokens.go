@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *http.Request) {
+	var revocation models.TokenRevocation
+
+	if err := json.NewDecoder(r.Body).Decode(&revocation); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	claims, err := s.verifier.ParseBearer("Bearer " + revocation.Token)
+	if err != nil {
+		s.errorResponse(w, r, "error parsing token", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	if err = s.revocations.RevokeToken(ctx, userInfo, claims); err != nil {
+		s.errorResponse(w, r, "error revoking token", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, "token revoked successfully")
+}
+
+func (s *Server) revokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	var revocation models.TokenRevocation
+
+	if err := json.NewDecoder(r.Body).Decode(&revocation); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	userID := userInfo.UserID
+	if revocation.UserID != nil {
+		userID = *revocation.UserID
+	}
+
+	if err := s.revocations.RevokeUserTokens(ctx, userInfo, userID, revocation.Before); err != nil {
+		s.errorResponse(w, r, "error revoking tokens", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, "tokens revoked successfully")
+}
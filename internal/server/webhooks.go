@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var webhook models.Webhook
+
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		s.errorResponse(w, r, "error decoding request body", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	newWebhook, err := s.service.CreateWebhook(ctx, userInfo, webhook)
+	if err != nil {
+		s.errorResponse(w, r, "error creating webhook", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusCreated, newWebhook)
+}
+
+func (s *Server) getWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	webhooks, err := s.service.GetWebhooks(ctx, userInfo)
+	if err != nil {
+		s.errorResponse(w, r, "error getting webhooks", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, webhooks)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	if err = s.service.DeleteWebhook(ctx, userInfo, models.WebhookID(webhookID)); err != nil {
+		s.errorResponse(w, r, "error deleting webhook", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, "webhook deleted successfully")
+}
+
+func (s *Server) getWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	request := parseGetRequest(r)
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	deliveries, err := s.service.GetWebhookDeliveries(ctx, userInfo, request, models.WebhookID(webhookID))
+	if err != nil {
+		s.errorResponse(w, r, "error getting webhook deliveries", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, deliveries)
+}
+
+func (s *Server) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", invalidRequest(err))
+
+		return
+	}
+
+	ctx := r.Context()
+	userInfo := s.getFromContext(ctx)
+
+	err = s.service.RedeliverWebhook(ctx, userInfo, models.WebhookID(webhookID), models.DeliveryID(deliveryID))
+	if err != nil {
+		s.errorResponse(w, r, "error redelivering webhook", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusAccepted, "webhook delivery scheduled")
+}
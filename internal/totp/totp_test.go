@@ -0,0 +1,90 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/totp"
+	"github.com/stretchr/testify/suite"
+)
+
+type TOTPTestSuite struct {
+	suite.Suite
+}
+
+func TestTOTPSetupSuite(t *testing.T) {
+	suite.Run(t, new(TOTPTestSuite))
+}
+
+// TestRFCVectors checks the SHA1 test vectors of RFC 6238, truncated to 6 digits.
+func (s *TOTPTestSuite) TestRFCVectors() {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, tc := range tests {
+		code, err := totp.Code(secret, time.Unix(tc.unix, 0))
+		s.Require().NoError(err)
+		s.Require().Equal(tc.code, code)
+	}
+}
+
+func (s *TOTPTestSuite) TestValidate() {
+	secret, err := totp.NewSecret()
+	s.Require().NoError(err)
+
+	now := time.Now()
+
+	code, err := totp.Code(secret, now)
+	s.Require().NoError(err)
+
+	step, ok := totp.Validate(secret, code, now)
+	s.Require().True(ok)
+	s.Require().Equal(totp.Step(now), step)
+
+	s.Run("previous step", func() {
+		_, ok := totp.Validate(secret, code, now.Add(totp.Period))
+		s.Require().True(ok)
+	})
+
+	s.Run("expired", func() {
+		_, ok := totp.Validate(secret, code, now.Add(3*totp.Period))
+		s.Require().False(ok)
+	})
+
+	s.Run("wrong code", func() {
+		_, ok := totp.Validate(secret, "00000", now)
+		s.Require().False(ok)
+
+		_, ok = totp.Validate(secret, "abcdef", now)
+		s.Require().False(ok)
+	})
+
+	s.Run("invalid secret", func() {
+		_, ok := totp.Validate("not base32!", code, now)
+		s.Require().False(ok)
+	})
+}
+
+func (s *TOTPTestSuite) TestURL() {
+	u, err := url.Parse(totp.URL("Wallet", "user@example.com", "JBSWY3DPEHPK3PXP"))
+	s.Require().NoError(err)
+
+	s.Require().Equal("otpauth", u.Scheme)
+	s.Require().Equal("totp", u.Host)
+	s.Require().Equal("/Wallet:user@example.com", u.Path)
+	s.Require().Equal("JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	s.Require().Equal("Wallet", u.Query().Get("issuer"))
+}
@@ -0,0 +1,86 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/Memonagi/wallet_project/internal/tracing"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TracingTestSuite struct {
+	suite.Suite
+	provider *tracing.Provider
+}
+
+func (s *TracingTestSuite) SetupSuite() {
+	var err error
+
+	s.provider, err = tracing.New(context.Background(), tracing.Config{
+		ServiceName: "test",
+		Exporter:    tracing.ExporterNone,
+	})
+	s.Require().NoError(err)
+}
+
+func (s *TracingTestSuite) TearDownSuite() {
+	err := s.provider.Shutdown(context.Background())
+	s.Require().NoError(err)
+}
+
+func TestTracingSetupSuite(t *testing.T) {
+	suite.Run(t, new(TracingTestSuite))
+}
+
+func (s *TracingTestSuite) TestUnknownExporter() {
+	_, err := tracing.New(context.Background(), tracing.Config{Exporter: "jaeger"})
+	s.Require().Error(err)
+}
+
+func (s *TracingTestSuite) TestKafkaHeadersCarryTrace() {
+	ctx, parent := tracing.Tracer().Start(context.Background(), "transfer")
+	defer parent.End()
+
+	msg := &sarama.ProducerMessage{Topic: "transaction_updates"}
+
+	_, producerSpan := tracing.StartProducerSpan(ctx, msg)
+	producerSpan.End()
+
+	s.Require().NotEmpty(msg.Headers)
+
+	consumed := &sarama.ConsumerMessage{Topic: msg.Topic}
+	for i := range msg.Headers {
+		consumed.Headers = append(consumed.Headers, &msg.Headers[i])
+	}
+
+	ctx, consumerSpan := tracing.StartConsumerSpan(context.Background(), consumed)
+	defer consumerSpan.End()
+
+	s.Require().Equal(parent.SpanContext().TraceID().String(), tracing.TraceID(ctx))
+}
+
+func (s *TracingTestSuite) TestTraceIDEmptyWithoutSpan() {
+	s.Require().Empty(tracing.TraceID(context.Background()))
+}
+
+func (s *TracingTestSuite) TestLogHook() {
+	var buf bytes.Buffer
+
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(tracing.LogHook{})
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "request")
+	defer span.End()
+
+	logger.WithContext(ctx).Warn("something happened")
+
+	s.Require().Contains(buf.String(), `"trace_id":"`+span.SpanContext().TraceID().String()+`"`)
+	s.Require().Contains(buf.String(), `"span_id":"`+span.SpanContext().SpanID().String()+`"`)
+	s.Require().True(trace.SpanContextFromContext(ctx).IsValid())
+}
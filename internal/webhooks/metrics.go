@@ -0,0 +1,30 @@
+package webhooks
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type metrics struct {
+	deliveries *prometheus.CounterVec
+}
+
+const (
+	namespace = "wallet_service"
+	subsystem = "webhooks"
+)
+
+func newMetrics() *metrics {
+	metricList := metrics{
+		deliveries: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "delivery_attempts_total",
+				Help:      "Number of webhook delivery attempts by resulting status.",
+			},
+			[]string{"event", "status"}),
+	}
+
+	return &metricList
+}
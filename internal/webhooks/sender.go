@@ -0,0 +1,207 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type deliveries interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+}
+
+type Sender struct {
+	deliveries deliveries
+	client     *http.Client
+	metrics    *metrics
+}
+
+type Config struct {
+	Timeout time.Duration
+}
+
+type envelope struct {
+	ID        models.DeliveryID `json:"id"`
+	Type      string            `json:"type"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Data      json.RawMessage   `json:"data"`
+}
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	senderTicker   = time.Second
+	defaultTimeout = 10 * time.Second
+	batchSize      = 50
+	lease          = time.Minute
+	maxAttempts    = 8
+	baseBackoff    = 10 * time.Second
+	maxBackoff     = time.Hour
+	maxErrorLen    = 512
+)
+
+func New(deliveries deliveries, cfg Config) *Sender {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	return &Sender{
+		deliveries: deliveries,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// only the registered https url is trusted, a redirect could lead anywhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		metrics: newMetrics(),
+	}
+}
+
+func (s *Sender) Run(ctx context.Context) error {
+	t := time.NewTicker(senderTicker)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := s.sendDue(ctx); err != nil {
+				logrus.Warnf("failed to send webhooks: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Sender) sendDue(ctx context.Context) error {
+	due, err := s.deliveries.ClaimWebhookDeliveries(ctx, batchSize, lease)
+	if err != nil {
+		return fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	for _, delivery := range due {
+		delivery = s.send(ctx, delivery)
+
+		if err = s.deliveries.UpdateWebhookDelivery(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to update delivery %s: %w", uuid.UUID(delivery.ID), err)
+		}
+
+		s.metrics.deliveries.WithLabelValues(delivery.EventType, delivery.Status).Inc()
+	}
+
+	return nil
+}
+
+func (s *Sender) send(ctx context.Context, delivery models.WebhookDelivery) models.WebhookDelivery {
+	delivery.Attempts++
+
+	code, err := s.post(ctx, delivery)
+	if code != 0 {
+		delivery.ResponseCode = &code
+	}
+
+	if err == nil {
+		delivery.Status = models.DeliveryDelivered
+		delivery.LastError = nil
+
+		return delivery
+	}
+
+	errText := err.Error()
+	if len(errText) > maxErrorLen {
+		errText = errText[:maxErrorLen]
+	}
+
+	delivery.LastError = &errText
+
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.DeliveryFailed
+
+		return delivery
+	}
+
+	delivery.Status = models.DeliveryPending
+	delivery.NextAttempt = time.Now().Add(Backoff(delivery.Attempts))
+
+	return delivery
+}
+
+func (s *Sender) post(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(envelope{
+		ID:        delivery.ID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, uuid.UUID(delivery.ID).String())
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if err = resp.Body.Close(); err != nil {
+		logrus.WithContext(ctx).Warnf("failed to close webhook response body: %v", err)
+	}
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode) //nolint:err113
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value for the body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">".
+func Sign(secret string, timestamp int64, body []byte) string {
+	ts := strconv.FormatInt(timestamp, 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before the next attempt, doubling from baseBackoff up to maxBackoff.
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+
+	return delay
+}
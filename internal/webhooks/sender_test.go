@@ -0,0 +1,199 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/webhooks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeDeliveries struct {
+	mu      sync.Mutex
+	due     []models.WebhookDelivery
+	updated []models.WebhookDelivery
+}
+
+func (f *fakeDeliveries) ClaimWebhookDeliveries(_ context.Context, _ int,
+	_ time.Duration,
+) ([]models.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	due := f.due
+	f.due = nil
+
+	return due, nil
+}
+
+func (f *fakeDeliveries) UpdateWebhookDelivery(_ context.Context, delivery models.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.updated = append(f.updated, delivery)
+
+	return nil
+}
+
+func (f *fakeDeliveries) last() (models.WebhookDelivery, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.updated) == 0 {
+		return models.WebhookDelivery{}, false
+	}
+
+	return f.updated[len(f.updated)-1], true
+}
+
+func (f *fakeDeliveries) push(delivery models.WebhookDelivery) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.due = append(f.due, delivery)
+	f.updated = nil
+}
+
+type SenderTestSuite struct {
+	suite.Suite
+	cancelFn context.CancelFunc
+	store    *fakeDeliveries
+}
+
+func (s *SenderTestSuite) SetupSuite() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancelFn = cancel
+	s.store = &fakeDeliveries{}
+
+	sender := webhooks.New(s.store, webhooks.Config{Timeout: time.Second})
+
+	go func() {
+		s.Require().NoError(sender.Run(ctx))
+	}()
+}
+
+func (s *SenderTestSuite) TearDownSuite() {
+	s.cancelFn()
+}
+
+func TestSenderSetupSuite(t *testing.T) {
+	suite.Run(t, new(SenderTestSuite))
+}
+
+func (s *SenderTestSuite) run(delivery models.WebhookDelivery) models.WebhookDelivery {
+	s.store.push(delivery)
+
+	s.Require().Eventually(func() bool {
+		_, ok := s.store.last()
+
+		return ok
+	}, 3*time.Second, 10*time.Millisecond)
+
+	updated, _ := s.store.last()
+
+	return updated
+}
+
+func (s *SenderTestSuite) newDelivery(url string, attempts int) models.WebhookDelivery {
+	return models.WebhookDelivery{
+		ID:        models.DeliveryID(uuid.New()),
+		WebhookID: models.WebhookID(uuid.New()),
+		EventType: models.EventDeposit,
+		Payload:   json.RawMessage(`{"money":100}`),
+		Status:    models.DeliveryPending,
+		Attempts:  attempts,
+		CreatedAt: time.Now(),
+		URL:       url,
+		Secret:    "secret",
+	}
+}
+
+func (s *SenderTestSuite) TestDelivered() {
+	var (
+		body      []byte
+		signature string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(webhooks.SignatureHeader)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	delivery := s.run(s.newDelivery(srv.URL, 0))
+
+	s.Require().Equal(models.DeliveryDelivered, delivery.Status)
+	s.Require().Equal(1, delivery.Attempts)
+	s.Require().Equal(http.StatusNoContent, *delivery.ResponseCode)
+
+	timestamp, err := strconv.ParseInt(strings.TrimPrefix(strings.Split(signature, ",")[0], "t="), 10, 64)
+	s.Require().NoError(err)
+	s.Require().Equal(webhooks.Sign("secret", timestamp, body), signature)
+}
+
+func (s *SenderTestSuite) TestRetryWithBackoff() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	delivery := s.run(s.newDelivery(srv.URL, 2))
+
+	s.Require().Equal(models.DeliveryPending, delivery.Status)
+	s.Require().Equal(3, delivery.Attempts)
+	s.Require().NotNil(delivery.LastError)
+	s.Require().WithinDuration(time.Now().Add(webhooks.Backoff(3)), delivery.NextAttempt, time.Second)
+}
+
+func (s *SenderTestSuite) TestFailedAfterMaxAttempts() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	delivery := s.run(s.newDelivery(srv.URL, 7))
+
+	s.Require().Equal(models.DeliveryFailed, delivery.Status)
+	s.Require().Equal(8, delivery.Attempts)
+}
+
+func (s *SenderTestSuite) TestRedirectNotFollowed() {
+	var followed bool
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		followed = true
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer target.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	delivery := s.run(s.newDelivery(srv.URL, 0))
+
+	s.Require().False(followed)
+	s.Require().Equal(models.DeliveryPending, delivery.Status)
+	s.Require().Equal(http.StatusTemporaryRedirect, *delivery.ResponseCode)
+}
+
+func (s *SenderTestSuite) TestBackoff() {
+	s.Require().Equal(10*time.Second, webhooks.Backoff(1))
+	s.Require().Equal(20*time.Second, webhooks.Backoff(2))
+	s.Require().Equal(80*time.Second, webhooks.Backoff(4))
+	s.Require().Equal(time.Hour, webhooks.Backoff(20))
+}
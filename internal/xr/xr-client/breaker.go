@@ -0,0 +1,89 @@
+package xrclient
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	breakerClosed = iota
+	breakerHalfOpen
+	breakerOpen
+)
+
+// breaker opens after threshold failed calls in a row. After the cooldown a single call is let
+// through: the circuit closes if it succeeds and opens again if it fails.
+type breaker struct {
+	mu        sync.Mutex
+	state     int
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+	metrics   *metrics
+}
+
+func newBreaker(threshold int, cooldown time.Duration, m *metrics) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		metrics:   m,
+	}
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.setState(breakerHalfOpen)
+
+		return true
+	case breakerHalfOpen:
+		// a probe is already in flight
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.setState(breakerClosed)
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(breakerOpen)
+	}
+}
+
+// abandon ends a call that the caller gave up on, which says nothing about the XR service.
+// An abandoned probe lets the next call probe again.
+func (b *breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.setState(breakerOpen)
+	}
+}
+
+func (b *breaker) setState(state int) {
+	b.state = state
+	b.metrics.breakerState.Set(float64(state))
+}
@@ -5,60 +5,431 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
+	"sync"
+	"time"
 
 	"github.com/Memonagi/wallet_project/internal/models"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type Client struct {
-	cfg Config
+	cfg     Config
+	client  *http.Client
+	mu      sync.Mutex
+	cache   map[pair]cachedRate
+	tables  map[string]models.RatesTo
+	breaker *breaker
+	metrics *metrics
 }
 
 type Config struct {
 	ServerAddress string
+	// Token is the shared secret of the XR service, sent as a bearer token when set.
+	Token string
+	// Timeout bounds a single request to the XR service.
+	Timeout  time.Duration
+	CacheTTL time.Duration
+	// Retries is the number of retries of a failed request, with jittered exponential backoff from
+	// RetryBackoff. A negative value disables retries.
+	Retries      int
+	RetryBackoff time.Duration
+	// BreakerThreshold failed calls in a row open the circuit for BreakerCooldown.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type pair struct {
+	from, to string
+}
+
+type cachedRate struct {
+	quote models.XRResponse
+	// midOnly entries come from the bulk rates, which have no bid and ask.
+	midOnly   bool
+	expiresAt time.Time
 }
 
 const (
-	route = "/api/v1/xr?from=%v&to=%v"
+	route       = "/api/v1/xr"
+	ratesRoute  = "/api/v1/xr/rates"
+	healthRoute = "/healthz"
+
+	defaultTimeout          = 5 * time.Second
+	defaultCacheTTL         = time.Minute
+	defaultRetries          = 2
+	defaultRetryBackoff     = 100 * time.Millisecond
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+	dialTimeout             = 2 * time.Second
+)
+
+var (
+	ErrStatus = errors.New("wrong status code")
+	// ErrCircuitOpen is returned without calling the XR service while it is failing.
+	ErrCircuitOpen = errors.New("xrclient: circuit is open")
 )
 
 func New(cfg Config) *Client {
-	return &Client{cfg: cfg}
-}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	} else if cfg.Retries == 0 {
+		cfg.Retries = defaultRetries
+	}
+
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = defaultRetryBackoff
+	}
+
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = defaultBreakerThreshold
+	}
+
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaultBreakerCooldown
+	}
 
-var ErrStatus = errors.New("wrong status code")
+	transport, _ := http.DefaultTransport.(*http.Transport)
+	transport = transport.Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout}).DialContext
+	transport.ResponseHeaderTimeout = cfg.Timeout
 
+	m := newMetrics()
+
+	return &Client{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: otelhttp.NewTransport(transport),
+			Timeout:   cfg.Timeout,
+		},
+		cache:   make(map[pair]cachedRate),
+		tables:  make(map[string]models.RatesTo),
+		breaker: newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown, m),
+		metrics: m,
+	}
+}
+
+// GetRate returns the cached mid rate of the pair, or asks the XR service for it.
 func (c *Client) GetRate(ctx context.Context, from, to string) (float64, error) {
-	address := c.cfg.ServerAddress + fmt.Sprintf(route, from, to)
+	return c.GetRateAt(ctx, from, to, time.Time{})
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+// GetRateAt returns the mid rate of the pair that applied at the time, or the current rate for a zero time.
+// Only current rates are cached.
+func (c *Client) GetRateAt(ctx context.Context, from, to string, at time.Time) (float64, error) {
+	if entry, ok := c.cached(pair{from: from, to: to}); ok && at.IsZero() {
+		c.metrics.cache.WithLabelValues("hit").Inc()
+
+		return entry.quote.Rate, nil
+	}
+
+	quote, err := c.getQuote(ctx, from, to, at)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+		return 0, err
+	}
+
+	return quote.Rate, nil
+}
+
+// GetQuote returns the cached quote of the pair, or asks the XR service for it.
+func (c *Client) GetQuote(ctx context.Context, from, to string) (models.XRResponse, error) {
+	return c.GetQuoteAt(ctx, from, to, time.Time{})
+}
+
+// GetQuoteAt returns the quote of the pair that applied at the time, or the current quote for a zero time.
+// Only current quotes are cached.
+func (c *Client) GetQuoteAt(ctx context.Context, from, to string, at time.Time) (models.XRResponse, error) {
+	if entry, ok := c.cached(pair{from: from, to: to}); ok && at.IsZero() && !entry.midOnly {
+		c.metrics.cache.WithLabelValues("hit").Inc()
+
+		return entry.quote, nil
+	}
+
+	return c.getQuote(ctx, from, to, at)
+}
+
+// getQuote asks the XR service for the quote, and caches it when it is the current one. Rates at a time
+// are not cached, as every transaction asks for its own time.
+func (c *Client) getQuote(ctx context.Context, from, to string, at time.Time) (models.XRResponse, error) {
+	c.metrics.cache.WithLabelValues("miss").Inc()
+
+	query := url.Values{"from": {from}, "to": {to}}
+	if !at.IsZero() {
+		query.Set("at", at.UTC().Format(time.RFC3339Nano))
+	}
+
+	var quote models.XRResponse
+
+	if err := c.call(ctx, route, query, &quote); err != nil {
+		return models.XRResponse{}, err
+	}
+
+	if at.IsZero() {
+		c.store(map[pair]cachedRate{{from: from, to: to}: {quote: quote}})
+	}
+
+	return quote, nil
+}
+
+// GetRatesTo returns the current mid rates of every currency into the currency, from the cache or in
+// a single request, and caches them for the pairs into the currency. FetchedAt is when they were requested.
+func (c *Client) GetRatesTo(ctx context.Context, to string) (models.RatesTo, error) {
+	if rates, ok := c.cachedTable(to); ok {
+		c.metrics.cache.WithLabelValues("hit").Inc()
+
+		return rates, nil
+	}
+
+	c.metrics.cache.WithLabelValues("miss").Inc()
+
+	var response models.RatesTo
+
+	if err := c.call(ctx, ratesRoute, url.Values{"to": {to}}, &response); err != nil {
+		return models.RatesTo{}, err
+	}
+
+	response.FetchedAt = time.Now().UTC()
+
+	rates := make(map[pair]cachedRate, len(response.Rates))
+	for currency, rate := range response.Rates {
+		rates[pair{from: currency, to: to}] = cachedRate{
+			quote:   models.XRResponse{Rate: rate, Date: response.Date},
+			midOnly: true,
+		}
+	}
+
+	c.store(rates)
+	c.storeTable(to, response)
+
+	return response, nil
+}
+
+func (c *Client) cached(key pair) (cachedRate, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(c.cache, key)
+
+		return cachedRate{}, false
+	}
+
+	return entry, true
+}
+
+func (c *Client) cachedTable(to string) (models.RatesTo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rates, ok := c.tables[to]
+	if !ok || time.Since(rates.FetchedAt) > c.cfg.CacheTTL {
+		delete(c.tables, to)
+
+		return models.RatesTo{}, false
+	}
+
+	return rates, true
+}
+
+func (c *Client) storeTable(to string, rates models.RatesTo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tables[to] = rates
+}
+
+// store caches the entries and drops the expired ones, which are not read again when nobody asks
+// for their pair.
+func (c *Client) store(entries map[pair]cachedRate) {
+	now := time.Now()
+	expiresAt := now.Add(c.cfg.CacheTTL)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.cache {
+		if now.After(entry.expiresAt) {
+			delete(c.cache, key)
+		}
 	}
 
-	client := &http.Client{}
+	for key, entry := range entries {
+		entry.expiresAt = expiresAt
+		c.cache[key] = entry
+	}
+}
 
-	resp, err := client.Do(req)
+// call gets the route of the XR service into the result through the circuit breaker, retrying failures.
+func (c *Client) call(ctx context.Context, route string, query url.Values, result any) error {
+	if !c.breaker.allow() {
+		return fmt.Errorf("%w: %w", ErrCircuitOpen, models.ErrRatesUnavailable)
+	}
+
+	if err := c.getWithRetries(ctx, route, query, result); err != nil {
+		if ctx.Err() != nil {
+			// the caller gave up, the XR service didn't fail
+			c.breaker.abandon()
+
+			return fmt.Errorf("xrclient: %w", err)
+		}
+
+		if !retryable(err) {
+			c.breaker.success()
+
+			return err
+		}
+
+		c.breaker.failure()
+
+		return fmt.Errorf("%w: %w", err, models.ErrRatesUnavailable)
+	}
+
+	c.breaker.success()
+
+	return nil
+}
+
+func (c *Client) getWithRetries(ctx context.Context, route string, query url.Values, result any) error {
+	for attempt := 0; ; attempt++ {
+		err := c.get(ctx, route, query, result)
+		if err == nil || ctx.Err() != nil || !retryable(err) || attempt >= c.cfg.Retries {
+			return err
+		}
+
+		c.metrics.retries.Inc()
+
+		// full jitter: a random wait up to the exponential backoff
+		backoff := c.cfg.RetryBackoff << attempt
+		wait := time.Duration(rand.Int64N(int64(backoff)) + 1) //nolint:gosec
+
+		t := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+
+			return fmt.Errorf("xrclient: %w", ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+func (c *Client) get(ctx context.Context, route string, query url.Values, result any) error {
+	req, err := c.newRequest(ctx, c.cfg.ServerAddress+route+"?"+query.Encode())
 	if err != nil {
-		return 0, fmt.Errorf("xrclient: failed to send request: %w", err)
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("xrclient: failed to send request: %w", err)
 	}
 
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			logrus.Warnf("xrclient: failed to close response body: %v", err)
+			logrus.WithContext(ctx).Warnf("xrclient: failed to close response body: %v", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, ErrStatus
+		var problem models.Problem
+
+		if err = json.NewDecoder(resp.Body).Decode(&problem); err == nil {
+			switch problem.Code {
+			case models.ErrorCode(models.ErrWrongCurrency):
+				return fmt.Errorf("%w: %w", ErrStatus, models.ErrWrongCurrency)
+			case models.ErrorCode(models.ErrRatesNotFound):
+				return fmt.Errorf("%w: %w", ErrStatus, models.ErrRatesNotFound)
+			}
+		}
+
+		return &statusError{code: resp.StatusCode}
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("xrclient: failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// newRequest creates a request to the XR service with the token of the client.
+func (c *Client) newRequest(ctx context.Context, address string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	var response models.XRResponse
+	if c.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	}
+
+	return req, nil
+}
+
+// statusError is an unexpected status of the XR service. It unwraps to ErrStatus.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%v: %d", ErrStatus, e.code)
+}
+
+func (e *statusError) Unwrap() error {
+	return ErrStatus
+}
 
-	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return 0, fmt.Errorf("xrclient: failed to decode response: %w", err)
+// retryable reports whether the error is a failure of the XR service rather than of the request:
+// transport errors, 5xx and 429 statuses. Only those are retried and count against the breaker.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, models.ErrWrongCurrency) ||
+		errors.Is(err, models.ErrRatesNotFound) {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code >= http.StatusInternalServerError || statusErr.code == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// Check calls the liveness endpoint of the XR service.
+func (c *Client) Check(ctx context.Context) error {
+	req, err := c.newRequest(ctx, c.cfg.ServerAddress+healthRoute)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("xrclient: failed to send request: %w", err)
+	}
+
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithContext(ctx).Warnf("xrclient: failed to close response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %d", ErrStatus, resp.StatusCode)
 	}
 
-	return response.Rate, nil
+	return nil
 }
@@ -0,0 +1,281 @@
+package xrclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	xrclient "github.com/Memonagi/wallet_project/internal/xr/xr-client"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	token           = "secret"
+	cacheTTL        = 100 * time.Millisecond
+	breakerCooldown = 200 * time.Millisecond
+	timeout         = 100 * time.Millisecond
+)
+
+type ClientTestSuite struct {
+	suite.Suite
+	server   *httptest.Server
+	client   *xrclient.Client
+	requests atomic.Int32
+	// failures is the number of requests answered with 503 before the service recovers.
+	failures atomic.Int32
+	delay    atomic.Int64
+}
+
+func (s *ClientTestSuite) SetupSuite() {
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.client = xrclient.New(xrclient.Config{
+		ServerAddress:    s.server.URL,
+		Token:            token,
+		Timeout:          timeout,
+		CacheTTL:         cacheTTL,
+		Retries:          2,
+		RetryBackoff:     time.Millisecond,
+		BreakerThreshold: 2,
+		BreakerCooldown:  breakerCooldown,
+	})
+}
+
+func (s *ClientTestSuite) TearDownSuite() {
+	s.server.Close()
+}
+
+func (s *ClientTestSuite) SetupTest() {
+	s.requests.Store(0)
+	s.failures.Store(0)
+	s.delay.Store(0)
+}
+
+func TestClientSetupSuite(t *testing.T) {
+	suite.Run(t, new(ClientTestSuite))
+}
+
+func (s *ClientTestSuite) handle(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+
+	time.Sleep(time.Duration(s.delay.Load()))
+
+	if r.URL.Path == "/healthz" {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+
+		return
+	}
+
+	if s.failures.Add(-1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return
+	}
+
+	if r.URL.Query().Get("to") == "XYZ" {
+		w.Header().Set("Content-Type", models.ProblemContentType)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(models.NewProblem(http.StatusNotFound, "", models.ErrWrongCurrency))
+
+		return
+	}
+
+	if r.URL.Path == "/api/v1/xr/rates" {
+		_ = json.NewEncoder(w).Encode(models.RatesTo{
+			To:    r.URL.Query().Get("to"),
+			Rates: map[string]float64{"USD": 1, "EUR": 1.08, "GBP": 1.28, "CAD": 0.73},
+		})
+
+		return
+	}
+
+	if at := r.URL.Query().Get("at"); at != "" {
+		if _, err := time.Parse(time.RFC3339Nano, at); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(models.XRResponse{Rate: 1.2})
+
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(models.XRResponse{Rate: 1.5, Bid: 1.4, Ask: 1.6})
+}
+
+func (s *ClientTestSuite) TestCache() {
+	rate, err := s.client.GetRate(context.Background(), "RUB", "USD")
+	s.Require().NoError(err)
+	s.Require().InDelta(1.5, rate, 1e-9)
+
+	_, err = s.client.GetRate(context.Background(), "RUB", "USD")
+	s.Require().NoError(err)
+	s.Require().Equal(int32(1), s.requests.Load())
+
+	time.Sleep(cacheTTL)
+
+	_, err = s.client.GetRate(context.Background(), "RUB", "USD")
+	s.Require().NoError(err)
+	s.Require().Equal(int32(2), s.requests.Load())
+}
+
+func (s *ClientTestSuite) TestGetRateAt() {
+	at := time.Date(2024, 10, 28, 12, 0, 0, 0, time.UTC)
+
+	rate, err := s.client.GetRateAt(context.Background(), "RUB", "AUD", at)
+	s.Require().NoError(err)
+	s.Require().InDelta(1.2, rate, 1e-9)
+
+	rate, err = s.client.GetRate(context.Background(), "RUB", "AUD")
+	s.Require().NoError(err)
+	s.Require().InDelta(1.5, rate, 1e-9)
+
+	// past rates are not cached, nor answered from the current rate
+	rate, err = s.client.GetRateAt(context.Background(), "RUB", "AUD", at)
+	s.Require().NoError(err)
+	s.Require().InDelta(1.2, rate, 1e-9)
+	s.Require().Equal(int32(3), s.requests.Load())
+}
+
+func (s *ClientTestSuite) TestGetRatesTo() {
+	rates, err := s.client.GetRatesTo(context.Background(), "USD")
+	s.Require().NoError(err)
+	s.Require().Len(rates.Rates, 4)
+	s.Require().InDelta(1.08, rates.Rates["EUR"], 1e-9)
+	s.Require().WithinDuration(time.Now(), rates.FetchedAt, time.Second)
+
+	// the pairs into the currency are served from the cache
+	rate, err := s.client.GetRate(context.Background(), "GBP", "USD")
+	s.Require().NoError(err)
+	s.Require().InDelta(1.28, rate, 1e-9)
+	s.Require().Equal(int32(1), s.requests.Load())
+
+	s.Run("cached rates keep the time they were fetched", func() {
+		cached, err := s.client.GetRatesTo(context.Background(), "USD")
+		s.Require().NoError(err)
+		s.Require().Equal(rates.FetchedAt, cached.FetchedAt)
+		s.Require().Equal(int32(1), s.requests.Load())
+	})
+
+	s.Run("expired rates are requested again", func() {
+		time.Sleep(cacheTTL)
+
+		_, err := s.client.GetRatesTo(context.Background(), "USD")
+		s.Require().NoError(err)
+		s.Require().Equal(int32(2), s.requests.Load())
+	})
+}
+
+func (s *ClientTestSuite) TestGetQuote() {
+	_, err := s.client.GetRatesTo(context.Background(), "EUR")
+	s.Require().NoError(err)
+
+	// the rates into a currency have no bid and ask, so the quote is requested
+	quote, err := s.client.GetQuote(context.Background(), "CAD", "EUR")
+	s.Require().NoError(err)
+	s.Require().InDelta(1.4, quote.Bid, 1e-9)
+	s.Require().InDelta(1.6, quote.Ask, 1e-9)
+	s.Require().Equal(int32(2), s.requests.Load())
+
+	_, err = s.client.GetQuote(context.Background(), "CAD", "EUR")
+	s.Require().NoError(err)
+	s.Require().Equal(int32(2), s.requests.Load())
+}
+
+func (s *ClientTestSuite) TestRetries() {
+	s.failures.Store(2)
+
+	rate, err := s.client.GetRate(context.Background(), "RUB", "EUR")
+	s.Require().NoError(err)
+	s.Require().InDelta(1.5, rate, 1e-9)
+	s.Require().Equal(int32(3), s.requests.Load())
+}
+
+func (s *ClientTestSuite) TestWrongCurrencyIsNotRetried() {
+	_, err := s.client.GetRate(context.Background(), "RUB", "XYZ")
+	s.Require().ErrorIs(err, models.ErrWrongCurrency)
+	s.Require().Equal(int32(1), s.requests.Load())
+}
+
+func (s *ClientTestSuite) TestTimeout() {
+	s.delay.Store(int64(2 * timeout))
+
+	start := time.Now()
+
+	_, err := s.client.GetRate(context.Background(), "RUB", "JPY")
+	s.Require().ErrorIs(err, models.ErrRatesUnavailable)
+	s.Require().Less(time.Since(start), 3*2*timeout)
+
+	// close the breaker again for the other tests
+	s.delay.Store(0)
+
+	_, err = s.client.GetRate(context.Background(), "RUB", "JPY")
+	s.Require().NoError(err)
+}
+
+func (s *ClientTestSuite) TestCallerDeadlineDoesNotOpenCircuit() {
+	s.delay.Store(int64(timeout / 2))
+
+	for range 3 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout/10)
+
+		_, err := s.client.GetRate(ctx, "RUB", "HKD")
+		s.Require().ErrorIs(err, context.DeadlineExceeded)
+
+		cancel()
+	}
+
+	s.delay.Store(0)
+
+	_, err := s.client.GetRate(context.Background(), "RUB", "HKD")
+	s.Require().NoError(err)
+}
+
+func (s *ClientTestSuite) TestCheck() {
+	// the server rejects health checks without the token
+	s.Require().NoError(s.client.Check(context.Background()))
+}
+
+func (s *ClientTestSuite) TestCircuitBreaker() {
+	s.failures.Store(1000)
+
+	for range 2 {
+		_, err := s.client.GetRate(context.Background(), "RUB", "CNY")
+		s.Require().ErrorIs(err, models.ErrRatesUnavailable)
+	}
+
+	requests := s.requests.Load()
+
+	_, err := s.client.GetRate(context.Background(), "RUB", "CNY")
+	s.Require().ErrorIs(err, xrclient.ErrCircuitOpen)
+	s.Require().ErrorIs(err, models.ErrRatesUnavailable)
+	s.Require().Equal(requests, s.requests.Load())
+
+	s.Run("failed probe opens the circuit again", func() {
+		time.Sleep(breakerCooldown)
+
+		_, err := s.client.GetRate(context.Background(), "RUB", "CNY")
+		s.Require().ErrorIs(err, models.ErrRatesUnavailable)
+
+		_, err = s.client.GetRate(context.Background(), "RUB", "CNY")
+		s.Require().ErrorIs(err, xrclient.ErrCircuitOpen)
+	})
+
+	s.Run("successful probe closes the circuit", func() {
+		s.failures.Store(0)
+		time.Sleep(breakerCooldown)
+
+		_, err := s.client.GetRate(context.Background(), "RUB", "CNY")
+		s.Require().NoError(err)
+
+		_, err = s.client.GetRate(context.Background(), "RUB", "CAD")
+		s.Require().NoError(err)
+	})
+}
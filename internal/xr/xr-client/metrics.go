@@ -0,0 +1,46 @@
+package xrclient
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type metrics struct {
+	cache        *prometheus.CounterVec
+	retries      prometheus.Counter
+	breakerState prometheus.Gauge
+}
+
+const (
+	namespace = "wallet_service"
+	subsystem = "xrclient"
+)
+
+func newMetrics() *metrics {
+	metricList := metrics{
+		cache: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "cache_requests_total",
+				Help:      "Number of rate lookups by cache result.",
+			},
+			[]string{"result"}),
+		retries: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "retries_total",
+				Help:      "Number of retried requests to the XR service.",
+			}),
+		breakerState: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "breaker_state",
+				Help:      "State of the XR service circuit breaker: 0 closed, 1 half-open, 2 open.",
+			}),
+	}
+
+	return &metricList
+}
@@ -0,0 +1,38 @@
+package xrrates
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type metrics struct {
+	lookups        *prometheus.CounterVec
+	tablePublished prometheus.Gauge
+}
+
+const (
+	namespace = "wallet_service"
+	subsystem = "xr_rates"
+)
+
+func newMetrics() *metrics {
+	metricList := metrics{
+		lookups: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "lookups_total",
+				Help:      "Number of rate lookups by source: local, fallback or stale.",
+			},
+			[]string{"source"}),
+		tablePublished: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "table_published_timestamp_seconds",
+				Help:      "Time the XR service published the local rate table.",
+			}),
+	}
+
+	return &metricList
+}
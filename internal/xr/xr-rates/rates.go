@@ -0,0 +1,157 @@
+package xrrates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+type fallback interface {
+	GetQuote(ctx context.Context, from, to string) (models.XRResponse, error)
+	GetQuoteAt(ctx context.Context, from, to string, at time.Time) (models.XRResponse, error)
+	GetRatesTo(ctx context.Context, to string) (models.RatesTo, error)
+}
+
+// Rates answers the current quotes from the rate table the XR service streams. A pair missing from the
+// table is asked from the XR service directly, while a quote from a table older than MaxAge is refused
+// rather than used.
+type Rates struct {
+	fallback fallback
+	maxAge   time.Duration
+	mu       sync.RWMutex
+	table    models.RateTable
+	metrics  *metrics
+}
+
+type Config struct {
+	// MaxAge is how long after it was published a rate table is used.
+	MaxAge time.Duration
+}
+
+const defaultMaxAge = 5 * time.Minute
+
+func New(cfg Config, fallback fallback) *Rates {
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaultMaxAge
+	}
+
+	return &Rates{
+		fallback: fallback,
+		maxAge:   cfg.MaxAge,
+		metrics:  newMetrics(),
+	}
+}
+
+// UpdateRates replaces the rate table, unless the table is older than the current one.
+func (r *Rates) UpdateRates(table models.RateTable) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if table.PublishedAt.Before(r.table.PublishedAt) {
+		return
+	}
+
+	r.table = table
+	r.metrics.tablePublished.Set(float64(table.PublishedAt.Unix()))
+}
+
+func (r *Rates) GetRate(ctx context.Context, from, to string) (float64, error) {
+	quote, err := r.GetQuote(ctx, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	return quote.Rate, nil
+}
+
+// GetQuote returns the quote of the pair from the table, and refuses it when the table is older than MaxAge.
+func (r *Rates) GetQuote(ctx context.Context, from, to string) (models.XRResponse, error) {
+	quote, found, fresh := r.local(strings.ToUpper(from), strings.ToUpper(to))
+
+	switch {
+	case found && fresh:
+		r.metrics.lookups.WithLabelValues("local").Inc()
+
+		return quote, nil
+	case found:
+		r.metrics.lookups.WithLabelValues("stale").Inc()
+
+		return models.XRResponse{}, fmt.Errorf("%w: rates of %s/%s are older than %v",
+			models.ErrRatesStale, from, to, r.maxAge)
+	}
+
+	quote, err := r.fallback.GetQuote(ctx, from, to)
+	if err != nil {
+		return models.XRResponse{}, fmt.Errorf("failed to get rate: %w", err)
+	}
+
+	r.metrics.lookups.WithLabelValues("fallback").Inc()
+
+	return quote, nil
+}
+
+// GetQuoteAt asks the XR service, as the table only has the current rates.
+func (r *Rates) GetQuoteAt(ctx context.Context, from, to string, at time.Time) (models.XRResponse, error) {
+	quote, err := r.fallback.GetQuoteAt(ctx, from, to, at)
+	if err != nil {
+		return models.XRResponse{}, fmt.Errorf("failed to get rate: %w", err)
+	}
+
+	return quote, nil
+}
+
+// GetRatesTo returns the rates of every currency into the currency from the table, fetched when the table
+// was published. When the table is out of date or has no rates into the currency, they are asked from
+// the XR service.
+func (r *Rates) GetRatesTo(ctx context.Context, to string) (models.RatesTo, error) {
+	rates, found, fresh := r.localTo(strings.ToUpper(to))
+	if found && fresh {
+		r.metrics.lookups.WithLabelValues("local").Inc()
+
+		return rates, nil
+	}
+
+	rates, err := r.fallback.GetRatesTo(ctx, to)
+	if err == nil {
+		r.metrics.lookups.WithLabelValues("fallback").Inc()
+
+		return rates, nil
+	}
+
+	if found {
+		r.metrics.lookups.WithLabelValues("stale").Inc()
+
+		return models.RatesTo{}, fmt.Errorf("%w: %w", models.ErrRatesStale, err)
+	}
+
+	return models.RatesTo{}, fmt.Errorf("failed to get rates: %w", err)
+}
+
+func (r *Rates) local(from, to string) (models.XRResponse, bool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quote, ok := r.table.Quotes[models.CurrencyPair(from, to)]
+
+	return quote, ok, time.Since(r.table.PublishedAt) <= r.maxAge
+}
+
+func (r *Rates) localTo(to string) (models.RatesTo, bool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := models.RatesTo{To: to, Rates: make(map[string]float64), FetchedAt: r.table.PublishedAt}
+
+	for pair, quote := range r.table.Quotes {
+		if from, ok := strings.CutSuffix(pair, "/"+to); ok {
+			rates.Rates[from] = quote.Rate
+			rates.Date = quote.Date
+		}
+	}
+
+	return rates, len(rates.Rates) > 0, time.Since(r.table.PublishedAt) <= r.maxAge
+}
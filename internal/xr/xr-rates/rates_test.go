@@ -0,0 +1,162 @@
+package xrrates_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	xrrates "github.com/Memonagi/wallet_project/internal/xr/xr-rates"
+	"github.com/stretchr/testify/suite"
+)
+
+const maxAge = 100 * time.Millisecond
+
+var errUnavailable = errors.New("unavailable")
+
+type fakeFallback struct {
+	mu    sync.Mutex
+	quote models.XRResponse
+	err   error
+	calls int
+}
+
+func (f *fakeFallback) GetRatesTo(_ context.Context, to string) (models.RatesTo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+
+	return models.RatesTo{To: to, Rates: map[string]float64{"RUB": f.quote.Rate}, FetchedAt: time.Now()}, f.err
+}
+
+func (f *fakeFallback) GetQuote(_ context.Context, _, _ string) (models.XRResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+
+	return f.quote, f.err
+}
+
+func (f *fakeFallback) GetQuoteAt(ctx context.Context, from, to string, _ time.Time) (models.XRResponse, error) {
+	return f.GetQuote(ctx, from, to)
+}
+
+func (f *fakeFallback) set(quote models.XRResponse, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.quote, f.err, f.calls = quote, err, 0
+}
+
+type RatesTestSuite struct {
+	suite.Suite
+	fallback *fakeFallback
+	rates    *xrrates.Rates
+}
+
+func (s *RatesTestSuite) SetupSuite() {
+	s.fallback = &fakeFallback{}
+	s.rates = xrrates.New(xrrates.Config{MaxAge: maxAge}, s.fallback)
+}
+
+func (s *RatesTestSuite) SetupTest() {
+	s.fallback.set(models.XRResponse{Rate: 3, Bid: 3, Ask: 3}, nil)
+}
+
+func TestRatesSetupSuite(t *testing.T) {
+	suite.Run(t, new(RatesTestSuite))
+}
+
+func table(publishedAt time.Time, rate float64) models.RateTable {
+	return models.RateTable{
+		Quotes:      map[string]models.XRResponse{"RUB/USD": {Rate: rate, Bid: rate, Ask: rate}},
+		PublishedAt: publishedAt,
+	}
+}
+
+func (s *RatesTestSuite) TestFreshRates() {
+	s.rates.UpdateRates(table(time.Now(), 1.5))
+
+	rate, err := s.rates.GetRate(context.Background(), "rub", "usd")
+	s.Require().NoError(err)
+	s.Require().InDelta(1.5, rate, 1e-9)
+	s.Require().Zero(s.fallback.calls)
+
+	s.Run("missing pair", func() {
+		quote, err := s.rates.GetQuote(context.Background(), "RUB", "EUR")
+		s.Require().NoError(err)
+		s.Require().InDelta(3, quote.Rate, 1e-9)
+		s.Require().Equal(1, s.fallback.calls)
+	})
+
+	s.Run("older table is ignored", func() {
+		s.rates.UpdateRates(table(time.Now().Add(-time.Second), 7))
+
+		rate, err := s.rates.GetRate(context.Background(), "RUB", "USD")
+		s.Require().NoError(err)
+		s.Require().InDelta(1.5, rate, 1e-9)
+	})
+}
+
+func (s *RatesTestSuite) TestStaleRates() {
+	s.rates.UpdateRates(table(time.Now(), 1.5))
+	time.Sleep(2 * maxAge)
+
+	_, err := s.rates.GetQuote(context.Background(), "RUB", "USD")
+	s.Require().ErrorIs(err, models.ErrRatesStale)
+	s.Require().Zero(s.fallback.calls)
+
+	s.Run("missing pair", func() {
+		quote, err := s.rates.GetQuote(context.Background(), "RUB", "EUR")
+		s.Require().NoError(err)
+		s.Require().InDelta(3, quote.Rate, 1e-9)
+		s.Require().Equal(1, s.fallback.calls)
+	})
+
+	s.Run("unavailable fallback without local rate", func() {
+		s.fallback.set(models.XRResponse{}, errUnavailable)
+
+		_, err := s.rates.GetQuote(context.Background(), "RUB", "EUR")
+		s.Require().ErrorIs(err, errUnavailable)
+		s.Require().NotErrorIs(err, models.ErrRatesStale)
+	})
+}
+
+func (s *RatesTestSuite) TestGetRatesTo() {
+	publishedAt := time.Now()
+	s.rates.UpdateRates(table(publishedAt, 1.5))
+
+	rates, err := s.rates.GetRatesTo(context.Background(), "usd")
+	s.Require().NoError(err)
+	s.Require().Equal(map[string]float64{"RUB": 1.5}, rates.Rates)
+	s.Require().True(publishedAt.Equal(rates.FetchedAt))
+	s.Require().Zero(s.fallback.calls)
+
+	s.Run("no rates into the currency", func() {
+		rates, err := s.rates.GetRatesTo(context.Background(), "EUR")
+		s.Require().NoError(err)
+		s.Require().Equal("EUR", rates.To)
+		s.Require().Equal(1, s.fallback.calls)
+	})
+
+	s.Run("stale table with unavailable fallback", func() {
+		time.Sleep(maxAge)
+		s.fallback.set(models.XRResponse{}, errUnavailable)
+
+		_, err := s.rates.GetRatesTo(context.Background(), "USD")
+		s.Require().ErrorIs(err, models.ErrRatesStale)
+	})
+}
+
+func (s *RatesTestSuite) TestGetQuoteAt() {
+	s.rates.UpdateRates(table(time.Now(), 1.5))
+
+	quote, err := s.rates.GetQuoteAt(context.Background(), "RUB", "USD", time.Now().Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Require().InDelta(3, quote.Rate, 1e-9)
+	s.Require().Equal(1, s.fallback.calls)
+}
@@ -0,0 +1,116 @@
+package xrserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type ctxKey struct{}
+
+// expireRequest optionally schedules the expiry, which is immediate otherwise.
+type expireRequest struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// requireAdmin lets through bearer tokens of admins that are not revoked and have the admin scope,
+// and keeps their user id as the actor of changes.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.verifier.ParseBearer(r.Header.Get("Authorization"))
+		if err == nil {
+			err = s.revocations.Check(claims)
+		}
+
+		if err != nil {
+			s.errorResponse(w, r, "authorization error", err)
+
+			return
+		}
+
+		if claims.Role != models.RoleAdmin {
+			s.errorResponse(w, r, "authorization error", models.ErrAdminRequired)
+
+			return
+		}
+
+		if !claims.UserInfo().HasScope(models.ScopeAdmin) {
+			s.errorResponse(w, r, "authorization error", models.ErrInsufficientScope)
+
+			return
+		}
+
+		actor := uuid.UUID(claims.UserID).String()
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, actor)))
+	})
+}
+
+func getActor(ctx context.Context) string {
+	actor, _ := ctx.Value(ctxKey{}).(string)
+
+	return actor
+}
+
+func (s *Server) getRateOverrides(w http.ResponseWriter, r *http.Request) {
+	s.okResponse(w, r, http.StatusOK, s.service.GetRateOverrides())
+}
+
+func (s *Server) setRateOverride(w http.ResponseWriter, r *http.Request) {
+	var override models.RateOverride
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&override); err != nil {
+		s.errorResponse(w, r, "error decoding request body", fmt.Errorf("%w: %w", models.ErrInvalidRequest, err))
+
+		return
+	}
+
+	override, err := s.service.SetRateOverride(override, getActor(r.Context()))
+	if err != nil {
+		s.errorResponse(w, r, "error setting rate", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusCreated, override)
+}
+
+func (s *Server) expireRateOverride(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.errorResponse(w, r, "error parsing uuid", fmt.Errorf("%w: %w", models.ErrInvalidRequest, err))
+
+		return
+	}
+
+	var request expireRequest
+
+	if r.ContentLength != 0 {
+		if err = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&request); err != nil {
+			s.errorResponse(w, r, "error decoding request body", fmt.Errorf("%w: %w", models.ErrInvalidRequest, err))
+
+			return
+		}
+	}
+
+	override, err := s.service.ExpireRateOverride(models.RateOverrideID(id), request.ExpiresAt, getActor(r.Context()))
+	if err != nil {
+		s.errorResponse(w, r, "error expiring rate", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, override)
+}
+
+func (s *Server) getRateChanges(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	s.okResponse(w, r, http.StatusOK, s.service.GetRateChanges(queryParams.Get("from"), queryParams.Get("to")))
+}
@@ -0,0 +1,57 @@
+package xrserver
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type metrics struct {
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+const (
+	namespace = "xr_service"
+	subsystem = "server"
+)
+
+func newMetrics() *metrics {
+	metricList := metrics{
+		requestsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "http_request_total",
+				Help:      "Total number of HTTP requests.",
+			},
+			[]string{"endpoint", "status"}),
+
+		requestDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "http_request_duration_seconds",
+				Help:      "Duration of HTTP requests.",
+			},
+			[]string{"endpoint"}),
+	}
+
+	return &metricList
+}
+
+func (m *metrics) trackHTTPRequest(start time.Time, r *http.Request, status int) {
+	endpoint := r.Method + r.URL.Path
+
+	// the route pattern keeps ids out of the labels
+	if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+		endpoint = r.Method + routeCtx.RoutePattern()
+	}
+
+	m.requestsTotal.WithLabelValues(endpoint, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
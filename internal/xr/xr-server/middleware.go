@@ -0,0 +1,53 @@
+package xrserver
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// requireToken lets through the services that hold the shared token. Every caller is let through
+// when no token is configured.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) != 1 {
+			s.errorResponse(w, r, "authorization error", models.ErrInvalidToken)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// track logs and measures the requests.
+func (s *Server) track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		defer func() {
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			s.metrics.trackHTTPRequest(start, r, status)
+
+			logrus.WithContext(r.Context()).WithFields(logrus.Fields{
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"status":     status,
+				"duration":   time.Since(start).String(),
+				"request_id": middleware.GetReqID(r.Context()),
+			}).Info("request served")
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
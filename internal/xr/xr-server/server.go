@@ -8,56 +8,132 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Memonagi/wallet_project/internal/health"
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
 	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/tracing"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 type service interface {
-	GetRate(request models.XRRequest) (float64, error)
+	GetRate(request models.XRRequest) (models.XRResponse, error)
+	GetRates(base string, at time.Time) (models.Rates, error)
+	GetRatesTo(to string, at time.Time) (models.RatesTo, error)
+	Convert(request models.XRConvertRequest) (models.XRConvertResponse, error)
+	SetRateOverride(override models.RateOverride, actor string) (models.RateOverride, error)
+	ExpireRateOverride(id models.RateOverrideID, at time.Time, actor string) (models.RateOverride, error)
+	GetRateOverrides() []models.RateOverride
+	GetRateChanges(from, to string) []models.RateChange
+}
+
+type verifier interface {
+	ParseBearer(header string) (*jwtclaims.Claims, error)
+}
+
+type revocations interface {
+	Check(claims *jwtclaims.Claims) error
 }
 
 type Server struct {
-	service service
-	server  *http.Server
-	port    int
+	service     service
+	verifier    verifier
+	revocations revocations
+	server      *http.Server
+	port        int
+	token       string
+	metrics     *metrics
+	health      *health.Health
+	drain       time.Duration
 }
 
 type Config struct {
 	Port int
+	// Token is the shared secret the services send as a bearer token. The rates are public when it is empty.
+	Token string
+	// DrainDelay is how long the server keeps serving after readiness starts failing on shutdown,
+	// so that load balancers stop routing to it before the listener is closed.
+	DrainDelay time.Duration
 }
 
 const (
 	readHeaderTimeout = 5 * time.Second
 	gracefulTimeout   = 10 * time.Second
+	maxConversions    = 100
+	maxBodySize       = 1 << 20
 )
 
-func New(port int, service service) *Server {
+func New(cfg Config, service service, verifier verifier, revocations revocations) *Server {
 	r := chi.NewRouter()
 
 	s := Server{
-		service: service,
+		service:     service,
+		verifier:    verifier,
+		revocations: revocations,
 		server: &http.Server{
-			Addr:              fmt.Sprintf(":%d", port),
-			Handler:           r,
+			Addr:              fmt.Sprintf(":%d", cfg.Port),
+			Handler:           otelhttp.NewHandler(r, "http.server", otelhttp.WithFilter(notProbe)),
 			ReadHeaderTimeout: readHeaderTimeout,
 		},
-		port: port,
+		port:    cfg.Port,
+		token:   cfg.Token,
+		metrics: newMetrics(),
+		health:  health.New(),
+		drain:   cfg.DrainDelay,
 	}
 
+	if s.token == "" {
+		logrus.Warn("xr-server: no token is configured, the rates are served to anyone")
+	}
+
+	r.Use(tracing.Route)
+
+	r.Get("/metrics", promhttp.Handler().ServeHTTP)
+	r.Get("/healthz", s.health.Live)
+	r.Get("/readyz", s.health.Ready)
+
 	r.Route("/api/v1/xr", func(r chi.Router) {
-		r.Get("/", s.readExchangeRate)
+		r.Use(middleware.RequestID)
+		r.Use(middleware.Recoverer)
+		r.Use(s.track)
+
+		r.Group(func(r chi.Router) {
+			r.Use(s.requireToken)
+
+			r.Get("/", s.readExchangeRate)
+			r.Get("/rates", s.readExchangeRates)
+			r.Post("/convert", s.convert)
+		})
+
+		r.Route("/admin/rates", func(r chi.Router) {
+			r.Use(s.requireAdmin)
+
+			r.Get("/", s.getRateOverrides)
+			r.Post("/", s.setRateOverride)
+			r.Post("/{id}/expire", s.expireRateOverride)
+			r.Get("/history", s.getRateChanges)
+		})
 	})
 
 	return &s
 }
 
+// notProbe keeps scrapes and health probes out of traces.
+func notProbe(r *http.Request) bool {
+	return r.URL.Path != "/metrics" && r.URL.Path != "/healthz" && r.URL.Path != "/readyz"
+}
+
 func (s *Server) Run(ctx context.Context) error {
 	logrus.Infof("starting xr-server on port %d", s.port)
 
 	go func() {
 		<-ctx.Done()
 		logrus.Infof("shutting down xr-server on port %d", s.port)
+		s.health.SetShuttingDown()
+		time.Sleep(s.drain)
 
 		gracefulCtx, cancel := context.WithTimeout(context.Background(), gracefulTimeout)
 		defer cancel()
@@ -70,64 +146,143 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}()
 
-	if err := s.server.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
-		logrus.Errorf("error listening on port %d: %v", s.port, err)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error listening on port %d: %w", s.port, err)
 	}
 
 	return nil
 }
 
-func (s *Server) errorResponse(w http.ResponseWriter, errorText string, err error) {
+func (s *Server) errorResponse(w http.ResponseWriter, r *http.Request, errorText string, err error) {
 	statusCode := http.StatusInternalServerError
 
-	if errors.Is(err, models.ErrWrongCurrency) {
+	switch {
+	case errors.Is(err, models.ErrWrongCurrency) || errors.Is(err, models.ErrRatesNotFound) ||
+		errors.Is(err, models.ErrRateOverrideNotFound):
 		statusCode = http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidRequest):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, models.ErrInvalidToken) || errors.Is(err, models.ErrTokenRevoked):
+		statusCode = http.StatusUnauthorized
+	case errors.Is(err, models.ErrAdminRequired) || errors.Is(err, models.ErrInsufficientScope):
+		statusCode = http.StatusForbidden
 	}
 
-	errResp := fmt.Errorf("%s: %w", errorText, err).Error()
 	if statusCode == http.StatusInternalServerError {
-		errResp = http.StatusText(http.StatusInternalServerError)
-
-		logrus.Warn(err.Error())
+		logrus.WithContext(r.Context()).Warn(err.Error())
 	}
 
-	response, err := json.Marshal(errResp)
-	if err != nil {
-		logrus.Warnf("error marshalling response: %v", err)
-	}
+	problem := models.NewProblem(statusCode, fmt.Errorf("%s: %w", errorText, err).Error(), err)
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+	problem.TraceID = tracing.TraceID(r.Context())
 
+	w.Header().Set("Content-Type", models.ProblemContentType)
 	w.WriteHeader(statusCode)
 
-	if _, err := w.Write(response); err != nil {
-		logrus.Warnf("error writing response: %v", err)
+	if err = json.NewEncoder(w).Encode(problem); err != nil {
+		logrus.WithContext(r.Context()).Warnf("error encoding response: %v", err)
 	}
 }
 
-func (s *Server) okResponse(w http.ResponseWriter, status int, response any) {
+func (s *Server) okResponse(w http.ResponseWriter, r *http.Request, status int, response any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logrus.Warnf("error encoding response: %v", err)
+		logrus.WithContext(r.Context()).Warnf("error encoding response: %v", err)
 	}
 }
 
 func (s *Server) readExchangeRate(w http.ResponseWriter, r *http.Request) {
-	request := getQueryParams(r)
+	request, err := getQueryParams(r)
+	if err != nil {
+		s.errorResponse(w, r, "error reading request", err)
+
+		return
+	}
+
+	response, err := s.service.GetRate(request)
+	if err != nil {
+		s.errorResponse(w, r, "error getting rate", fmt.Errorf("%w", err))
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, response)
+}
+
+// readExchangeRates returns the rates of every currency from the base, or into the currency of to.
+func (s *Server) readExchangeRates(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+
+	base, to := queryParams.Get("base"), queryParams.Get("to")
+	if (base == "") == (to == "") {
+		s.errorResponse(w, r, "error reading request",
+			fmt.Errorf("%w: either base or to is required", models.ErrInvalidRequest))
+
+		return
+	}
+
+	var at time.Time
+
+	if value := queryParams.Get("at"); value != "" {
+		var err error
+
+		if at, err = parseTime(value); err != nil {
+			s.errorResponse(w, r, "error reading request", err)
+
+			return
+		}
+	}
+
+	var (
+		rates any
+		err   error
+	)
+
+	if to != "" {
+		rates, err = s.service.GetRatesTo(to, at)
+	} else {
+		rates, err = s.service.GetRates(base, at)
+	}
 
-	rate, err := s.service.GetRate(request)
 	if err != nil {
-		s.errorResponse(w, "error getting rate", fmt.Errorf("%w", err))
+		s.errorResponse(w, r, "error getting rates", err)
 
 		return
 	}
 
-	response := models.XRResponse{Rate: rate}
+	s.okResponse(w, r, http.StatusOK, rates)
+}
+
+func (s *Server) convert(w http.ResponseWriter, r *http.Request) {
+	var request models.XRConvertRequest
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&request); err != nil {
+		s.errorResponse(w, r, "error decoding request body", fmt.Errorf("%w: %w", models.ErrInvalidRequest, err))
+
+		return
+	}
+
+	if len(request.Conversions) == 0 || len(request.Conversions) > maxConversions {
+		s.errorResponse(w, r, "error reading request",
+			fmt.Errorf("%w: from 1 to %d conversions are allowed", models.ErrInvalidRequest, maxConversions))
+
+		return
+	}
 
-	s.okResponse(w, http.StatusOK, response)
+	response, err := s.service.Convert(request)
+	if err != nil {
+		s.errorResponse(w, r, "error converting", err)
+
+		return
+	}
+
+	s.okResponse(w, r, http.StatusOK, response)
 }
 
-func getQueryParams(r *http.Request) models.XRRequest {
+func getQueryParams(r *http.Request) (models.XRRequest, error) {
 	queryParams := r.URL.Query()
 
 	xr := models.XRRequest{
@@ -143,5 +298,28 @@ func getQueryParams(r *http.Request) models.XRRequest {
 		xr.ToCurrency = t
 	}
 
-	return xr
+	if at := queryParams.Get("at"); at != "" {
+		t, err := parseTime(at)
+		if err != nil {
+			return models.XRRequest{}, err
+		}
+
+		xr.At = t
+	}
+
+	return xr, nil
+}
+
+// parseTime reads an RFC 3339 time or a date, which stands for the end of that day in UTC.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid time %q", models.ErrInvalidRequest, value)
+	}
+
+	return day.Add(24*time.Hour - time.Nanosecond), nil //nolint:mnd
 }
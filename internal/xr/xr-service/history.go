@@ -0,0 +1,99 @@
+package xrservice
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+// history stores rate snapshots as JSON lines appended to a file.
+type history struct {
+	path string
+}
+
+func (h *history) load() ([]models.Rates, error) {
+	if h.path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(h.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error opening rate history: %w", err)
+	}
+
+	defer file.Close() //nolint:errcheck
+
+	var snapshots []models.Rates
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20) //nolint:mnd
+
+	for scanner.Scan() {
+		var rates models.Rates
+
+		if err = json.Unmarshal(scanner.Bytes(), &rates); err != nil {
+			return nil, fmt.Errorf("error decoding rate history: %w", err)
+		}
+
+		// a later line of the same date is a correction of the earlier one
+		if n := len(snapshots); n > 0 && snapshots[n-1].Date.Equal(rates.Date) {
+			snapshots[n-1] = rates
+
+			continue
+		}
+
+		snapshots = append(snapshots, rates)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rate history: %w", err)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Date.Before(snapshots[j].Date)
+	})
+
+	return snapshots, nil
+}
+
+func (h *history) append(rates models.Rates) error {
+	if h.path == "" {
+		return nil
+	}
+
+	return appendLine(h.path, rates)
+}
+
+// appendLine writes the value as a JSON line at the end of the file.
+func appendLine(path string, value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error encoding %T: %w", value, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:mnd
+	if err != nil {
+		return fmt.Errorf("error opening %s: %w", path, err)
+	}
+
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("error closing %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -7,6 +7,9 @@ import (
 
 type metrics struct {
 	externalRequestDuration *prometheus.HistogramVec
+	refreshes               *prometheus.CounterVec
+	overrideChanges         *prometheus.CounterVec
+	publishes               *prometheus.CounterVec
 }
 
 const (
@@ -24,6 +27,30 @@ func newMetric() *metrics {
 				Help:      "Duration of external HTTP requests.",
 			},
 			[]string{"endpoint"}),
+		refreshes: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rate_refreshes_total",
+				Help:      "Number of rate reloads from the provider by result.",
+			},
+			[]string{"result"}),
+		overrideChanges: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rate_override_changes_total",
+				Help:      "Number of changes of rates set by hand by action.",
+			},
+			[]string{"action"}),
+		publishes: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: subsystem,
+				Name:      "rate_publishes_total",
+				Help:      "Number of rate tables published to the wallet service by result.",
+			},
+			[]string{"result"}),
 	}
 
 	return &metricList
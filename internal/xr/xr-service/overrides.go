@@ -0,0 +1,205 @@
+package xrservice
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+// overrides keeps the rates set by hand. Every change is appended to the file and replayed on start,
+// so the file is the audit history as well.
+type overrides struct {
+	mu      sync.RWMutex
+	path    string
+	items   map[models.RateOverrideID]models.RateOverride
+	changes []models.RateChange
+}
+
+func newOverrides(path string) (*overrides, error) {
+	o := &overrides{
+		path:  path,
+		items: make(map[models.RateOverrideID]models.RateOverride),
+	}
+
+	if path == "" {
+		return o, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return o, nil
+		}
+
+		return nil, fmt.Errorf("error opening rate overrides: %w", err)
+	}
+
+	defer file.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		var change models.RateChange
+
+		if err = json.Unmarshal(scanner.Bytes(), &change); err != nil {
+			return nil, fmt.Errorf("error decoding rate overrides: %w", err)
+		}
+
+		o.apply(change)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rate overrides: %w", err)
+	}
+
+	return o, nil
+}
+
+func (o *overrides) apply(change models.RateChange) {
+	o.items[change.Override.ID] = change.Override
+	o.changes = append(o.changes, change)
+}
+
+// record stores the change and applies it. The caller holds the lock.
+func (o *overrides) record(change models.RateChange) error {
+	if o.path != "" {
+		if err := appendLine(o.path, change); err != nil {
+			return err
+		}
+	}
+
+	o.apply(change)
+
+	return nil
+}
+
+func (o *overrides) set(override models.RateOverride, actor string) (models.RateOverride, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now().UTC()
+
+	override.ID = models.RateOverrideID(uuid.New())
+	if override.EffectiveFrom.Before(now) {
+		override.EffectiveFrom = now
+	}
+
+	if err := o.record(models.RateChange{
+		Action:   models.RateChangeSet,
+		Override: override,
+		Actor:    actor,
+		At:       now,
+	}); err != nil {
+		return models.RateOverride{}, err
+	}
+
+	return override, nil
+}
+
+// expire stops the override at the time, or now when the time has passed. A scheduled override
+// expired before it starts never applies.
+func (o *overrides) expire(id models.RateOverrideID, at time.Time, actor string) (models.RateOverride, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	override, ok := o.items[id]
+	if !ok {
+		return models.RateOverride{}, models.ErrRateOverrideNotFound
+	}
+
+	now := time.Now().UTC()
+
+	if override.ExpiresAt != nil && !override.ExpiresAt.After(now) {
+		return models.RateOverride{}, fmt.Errorf("%w: rate override has already expired", models.ErrInvalidRequest)
+	}
+
+	if at.Before(now) {
+		at = now
+	}
+
+	if at.Before(override.EffectiveFrom) {
+		at = override.EffectiveFrom
+	}
+
+	override.ExpiresAt = &at
+
+	if err := o.record(models.RateChange{
+		Action:   models.RateChangeExpire,
+		Override: override,
+		Actor:    actor,
+		At:       now,
+	}); err != nil {
+		return models.RateOverride{}, err
+	}
+
+	return override, nil
+}
+
+// find returns the override of the pair that applies at the time, the latest one to start
+// when they overlap.
+func (o *overrides) find(from, to string, at time.Time) (models.RateOverride, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var (
+		found models.RateOverride
+		ok    bool
+	)
+
+	for _, override := range o.items {
+		if override.From != from || override.To != to || at.Before(override.EffectiveFrom) ||
+			(override.ExpiresAt != nil && !at.Before(*override.ExpiresAt)) {
+			continue
+		}
+
+		if !ok || override.EffectiveFrom.After(found.EffectiveFrom) {
+			found, ok = override, true
+		}
+	}
+
+	return found, ok
+}
+
+func (o *overrides) list() []models.RateOverride {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	list := make([]models.RateOverride, 0, len(o.items))
+	for _, override := range o.items {
+		list = append(list, override)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].EffectiveFrom.Before(list[j].EffectiveFrom)
+	})
+
+	return list
+}
+
+// history returns the changes of the pair in either direction, or all changes for an empty pair.
+func (o *overrides) history(from, to string) []models.RateChange {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	changes := make([]models.RateChange, 0, len(o.changes))
+
+	for _, change := range o.changes {
+		pair := change.Override
+
+		if (from == "" || pair.From == from || pair.To == from) && (to == "" || pair.From == to || pair.To == to) {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,210 @@
+package xrservice
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Provider loads the current rates from a source.
+type Provider interface {
+	Rates(ctx context.Context) (models.Rates, error)
+}
+
+const (
+	fetchTimeout = 10 * time.Second
+	ecbBase      = "EUR"
+	ecbDate      = "2006-01-02"
+)
+
+var (
+	errNoRates    = errors.New("no rates")
+	errNoProvider = errors.New("no rate provider")
+)
+
+// DefaultRates are the built-in rates used when no other source is configured.
+func DefaultRates() models.Rates {
+	return models.Rates{
+		Base: "RUB",
+		Rates: map[string]float64{
+			"USD": 1.5, //nolint:mnd
+			"EUR": 1.6, //nolint:mnd
+			"RUB": 1,
+			"JPY": 0.8, //nolint:mnd
+			"CNY": 1.2, //nolint:mnd
+			"CAD": 1.3, //nolint:mnd
+			"AUD": 1.1, //nolint:mnd
+		},
+	}
+}
+
+// StaticProvider returns fixed rates, or the rates of a JSON file in the format of models.Rates.
+// The file is read on every refresh, so edits are picked up without a restart.
+type StaticProvider struct {
+	rates models.Rates
+	path  string
+}
+
+func NewStatic(rates models.Rates) *StaticProvider {
+	return &StaticProvider{rates: rates}
+}
+
+func NewStaticFile(path string) *StaticProvider {
+	return &StaticProvider{path: path}
+}
+
+func (p *StaticProvider) Rates(_ context.Context) (models.Rates, error) {
+	if p.path == "" {
+		return p.rates, nil
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return models.Rates{}, fmt.Errorf("error reading rates file: %w", err)
+	}
+
+	var rates models.Rates
+
+	if err = json.Unmarshal(data, &rates); err != nil {
+		return models.Rates{}, fmt.Errorf("error decoding rates file: %w", err)
+	}
+
+	return rates, nil
+}
+
+// ECBProvider reads a daily reference rates feed in the XML format of the European Central Bank,
+// from a file or an http(s) URL.
+type ECBProvider struct {
+	source string
+	client *http.Client
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func NewECB(source string) *ECBProvider {
+	return &ECBProvider{
+		source: source,
+		client: &http.Client{Timeout: fetchTimeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+}
+
+func (p *ECBProvider) Rates(ctx context.Context) (models.Rates, error) {
+	data, err := p.read(ctx)
+	if err != nil {
+		return models.Rates{}, err
+	}
+
+	var envelope ecbEnvelope
+
+	if err = xml.Unmarshal(data, &envelope); err != nil {
+		return models.Rates{}, fmt.Errorf("error decoding ecb feed: %w", err)
+	}
+
+	daily := envelope.Cube.Cube
+
+	date, err := time.Parse(ecbDate, daily.Time)
+	if err != nil {
+		return models.Rates{}, fmt.Errorf("error parsing ecb feed date: %w", err)
+	}
+
+	rates := models.Rates{
+		Base:  ecbBase,
+		Date:  date,
+		Rates: make(map[string]float64, len(daily.Rates)+1),
+	}
+
+	for _, rate := range daily.Rates {
+		rates.Rates[rate.Currency] = rate.Rate
+	}
+
+	return rates, nil
+}
+
+func (p *ECBProvider) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(p.source, "http://") && !strings.HasPrefix(p.source, "https://") {
+		data, err := os.ReadFile(p.source)
+		if err != nil {
+			return nil, fmt.Errorf("error reading ecb feed: %w", err)
+		}
+
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating ecb feed request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching ecb feed: %w", err)
+	}
+
+	defer func() {
+		if err = resp.Body.Close(); err != nil {
+			logrus.Warnf("error closing ecb feed response body: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching ecb feed: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ecb feed response: %w", err)
+	}
+
+	return data, nil
+}
+
+// ChainProvider returns the rates of the first provider that succeeds.
+type ChainProvider struct {
+	providers []Provider
+}
+
+func NewChain(providers ...Provider) *ChainProvider {
+	return &ChainProvider{providers: providers}
+}
+
+func (p *ChainProvider) Rates(ctx context.Context) (models.Rates, error) {
+	errs := make([]error, 0, len(p.providers))
+
+	for _, provider := range p.providers {
+		rates, err := provider.Rates(ctx)
+		if err == nil {
+			return rates, nil
+		}
+
+		logrus.WithContext(ctx).Warnf("rate provider %T failed, falling back: %v", provider, err)
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == 0 {
+		return models.Rates{}, errNoProvider
+	}
+
+	return models.Rates{}, errors.Join(errs...)
+}
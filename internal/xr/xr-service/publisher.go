@@ -0,0 +1,80 @@
+package xrservice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type producer interface {
+	ProduceRates(ctx context.Context, key, value string) error
+}
+
+// Publisher sends the rate table to the wallet service on every change of the rates, and every
+// Interval so that the wallet service knows its rates are current.
+type Publisher struct {
+	service  *Service
+	producer producer
+	interval time.Duration
+}
+
+type PublisherConfig struct {
+	Interval time.Duration
+}
+
+const defaultPublishInterval = time.Minute
+
+func NewPublisher(cfg PublisherConfig, service *Service, producer producer) *Publisher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultPublishInterval
+	}
+
+	return &Publisher{
+		service:  service,
+		producer: producer,
+		interval: cfg.Interval,
+	}
+}
+
+// Run publishes the rates until the context is done. A failed publish is retried with the next one.
+func (p *Publisher) Run(ctx context.Context) error {
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		if err := p.publish(ctx); err != nil {
+			p.service.metrics.publishes.WithLabelValues("failed").Inc()
+			logrus.Warnf("failed to publish rates: %v", err)
+		} else {
+			p.service.metrics.publishes.WithLabelValues("ok").Inc()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		case <-p.service.Changes():
+		}
+	}
+}
+
+func (p *Publisher) publish(ctx context.Context) error {
+	table, err := p.service.GetRateTable()
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(table)
+	if err != nil {
+		return fmt.Errorf("error encoding rate table: %w", err)
+	}
+
+	if err = p.producer.ProduceRates(ctx, "", string(value)); err != nil {
+		return fmt.Errorf("error producing rate table: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package xrservice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+// Quoting is how the rates of currency pairs are rounded and spread. Pairs are keyed by "FROM/TO",
+// where "*" matches any currency. The most specific key applies, Default when none matches.
+type Quoting struct {
+	Default PairQuoting            `json:"default"`
+	Pairs   map[string]PairQuoting `json:"pairs"`
+}
+
+type PairQuoting struct {
+	// Precision is the number of decimal places, 2 when unset.
+	Precision *int `json:"precision"`
+	// Mode is one of models.RoundingHalfEven, RoundingFloor and RoundingCeil, ceil when empty.
+	Mode string `json:"mode"`
+	// Spread is the difference of ask and bid relative to the mid rate, 0.01 for 1%.
+	// It isn't applied to the rates set by hand, which have their own bid and ask.
+	Spread float64 `json:"spread"`
+}
+
+const (
+	defaultPrecision = 2
+	maxPrecision     = 10
+	// snap is the scale at which float errors are dropped before rounding, so that 1.1 isn't ceiled to 1.11.
+	snap = 1e6
+)
+
+var errInvalidQuoting = errors.New("invalid quoting")
+
+// LoadQuoting reads the quoting from a JSON file.
+func LoadQuoting(path string) (Quoting, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Quoting{}, fmt.Errorf("error reading quoting: %w", err)
+	}
+
+	var quoting Quoting
+
+	if err = json.Unmarshal(data, &quoting); err != nil {
+		return Quoting{}, fmt.Errorf("error decoding quoting: %w", err)
+	}
+
+	return quoting, nil
+}
+
+// normalize fills in the defaults, upper-cases the pairs and rejects unknown modes.
+func (q *Quoting) normalize() error {
+	var err error
+
+	if q.Default, err = q.Default.normalize(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+
+	pairs := make(map[string]PairQuoting, len(q.Pairs))
+
+	for pair, quoting := range q.Pairs {
+		if quoting, err = quoting.normalize(); err != nil {
+			return fmt.Errorf("%s: %w", pair, err)
+		}
+
+		pairs[strings.ToUpper(pair)] = quoting
+	}
+
+	q.Pairs = pairs
+
+	return nil
+}
+
+func (p PairQuoting) normalize() (PairQuoting, error) {
+	if p.Precision == nil {
+		precision := defaultPrecision
+		p.Precision = &precision
+	}
+
+	if p.Mode == "" {
+		p.Mode = models.RoundingCeil
+	}
+
+	switch {
+	case *p.Precision < 0 || *p.Precision > maxPrecision:
+		return PairQuoting{}, fmt.Errorf("%w: precision %d", errInvalidQuoting, *p.Precision)
+	case p.Mode != models.RoundingHalfEven && p.Mode != models.RoundingFloor && p.Mode != models.RoundingCeil:
+		return PairQuoting{}, fmt.Errorf("%w: mode %q", errInvalidQuoting, p.Mode)
+	case p.Spread < 0 || p.Spread >= 1 || math.IsNaN(p.Spread):
+		return PairQuoting{}, fmt.Errorf("%w: spread %v", errInvalidQuoting, p.Spread)
+	}
+
+	return p, nil
+}
+
+// pair returns the quoting of the pair of upper-case currencies.
+func (q *Quoting) pair(from, to string) PairQuoting {
+	for _, key := range []string{models.CurrencyPair(from, to), models.CurrencyPair(from, "*"),
+		models.CurrencyPair("*", to)} {
+		if quoting, ok := q.Pairs[key]; ok {
+			return quoting
+		}
+	}
+
+	return q.Default
+}
+
+func (p PairQuoting) rounding() models.Rounding {
+	return models.Rounding{Precision: *p.Precision, Mode: p.Mode}
+}
+
+func (p PairQuoting) round(rate float64) float64 {
+	scale := math.Pow10(*p.Precision)
+	scaled := math.Round(rate*scale*snap) / snap
+
+	switch p.Mode {
+	case models.RoundingFloor:
+		scaled = math.Floor(scaled)
+	case models.RoundingCeil:
+		scaled = math.Ceil(scaled)
+	default:
+		scaled = math.RoundToEven(scaled)
+	}
+
+	return scaled / scale
+}
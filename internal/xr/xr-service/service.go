@@ -1,52 +1,538 @@
 package xrservice
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"math"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/sirupsen/logrus"
 )
 
+// Service answers rates from snapshots held in memory. The current rates are reloaded from the provider
+// periodically, and every change is kept as a new snapshot for lookups of past rates.
 type Service struct {
-	metrics *metrics
+	provider Provider
+	refresh  time.Duration
+	history  *history
+	// overrides are rates set by hand, which replace the provider rates of their pairs.
+	overrides *overrides
+	mu        sync.RWMutex
+	// snapshots are sorted by Date, the time from which their rates apply.
+	snapshots []models.Rates
+	// fallback tells that the only snapshot holds the fallback rates loaded at startup,
+	// which the first rates of the provider replace whatever their date.
+	fallback bool
+	quoting  Quoting
+	metrics  *metrics
+	// changes signals a change of the current rates to the publisher.
+	changes chan struct{}
 }
 
-func New() *Service {
-	return &Service{
-		metrics: newMetric(),
+type Config struct {
+	RefreshInterval time.Duration
+	// HistoryFile keeps the snapshots across restarts, in memory only when empty.
+	HistoryFile string
+	// OverridesFile keeps the rates set by hand and their changes, in memory only when empty.
+	OverridesFile string
+	Quoting       Quoting
+	// Fallback lets the service start when the provider fails, with the history when there is one,
+	// else with the fallback rates. It is never used at runtime.
+	Fallback Provider
+}
+
+const defaultRefreshInterval = time.Hour
+
+// New loads the history and the current rates, so the service doesn't start without them.
+func New(ctx context.Context, cfg Config, provider Provider) (*Service, error) {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultRefreshInterval
+	}
+
+	if err := cfg.Quoting.normalize(); err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		provider: provider,
+		refresh:  cfg.RefreshInterval,
+		history:  &history{path: cfg.HistoryFile},
+		quoting:  cfg.Quoting,
+		metrics:  newMetric(),
+		changes:  make(chan struct{}, 1),
+	}
+
+	snapshots, err := s.history.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.overrides, err = newOverrides(cfg.OverridesFile); err != nil {
+		return nil, err
+	}
+
+	s.snapshots = snapshots
+
+	if err = s.Refresh(ctx); err != nil {
+		if cfg.Fallback == nil {
+			return nil, err
+		}
+
+		if len(s.snapshots) > 0 {
+			logrus.Warnf("starting with the rates of the history: %v", err)
+
+			return s, nil
+		}
+
+		logrus.Warnf("starting with the fallback rates: %v", err)
+
+		if err = s.loadFallback(ctx, cfg.Fallback); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// loadFallback keeps the fallback rates in memory only, until the provider delivers.
+func (s *Service) loadFallback(ctx context.Context, fallback Provider) error {
+	rates, err := fallback.Rates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load fallback rates: %w", err)
+	}
+
+	if err = normalize(&rates); err != nil {
+		return err
+	}
+
+	rates.Date = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots = []models.Rates{rates}
+	s.fallback = true
+
+	return nil
+}
+
+// Run reloads the rates periodically. The previous rates are kept when a reload fails.
+func (s *Service) Run(ctx context.Context) error {
+	t := time.NewTicker(s.refresh)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if err := s.Refresh(ctx); err != nil {
+				logrus.Warnf("failed to refresh rates: %v", err)
+			}
+		}
+	}
+}
+
+func (s *Service) Refresh(ctx context.Context) error {
+	rates, err := s.provider.Rates(ctx)
+	if err != nil {
+		s.metrics.refreshes.WithLabelValues("failed").Inc()
+
+		return fmt.Errorf("failed to load rates: %w", err)
+	}
+
+	if err = normalize(&rates); err != nil {
+		s.metrics.refreshes.WithLabelValues("failed").Inc()
+
+		return err
+	}
+
+	if err = s.addSnapshot(rates); err != nil {
+		s.metrics.refreshes.WithLabelValues("failed").Inc()
+
+		return err
+	}
+
+	s.metrics.refreshes.WithLabelValues("ok").Inc()
+
+	return nil
+}
+
+// addSnapshot keeps the rates if they differ from the latest ones. Rates without a date apply from now on.
+func (s *Service) addSnapshot(rates models.Rates) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dated := !rates.Date.IsZero()
+	if !dated {
+		rates.Date = time.Now().UTC()
+	}
+
+	if len(s.snapshots) > 0 && !s.fallback {
+		latest := s.snapshots[len(s.snapshots)-1]
+
+		if latest.Base == rates.Base && maps.Equal(latest.Rates, rates.Rates) {
+			return nil
+		}
+
+		if rates.Date.Before(latest.Date) {
+			if dated {
+				logrus.Warnf("ignoring rates of %s older than the latest of %s", rates.Date, latest.Date)
+
+				return nil
+			}
+
+			// undated rates never apply before the latest snapshot
+			rates.Date = latest.Date
+		}
 	}
+
+	if err := s.history.append(rates); err != nil {
+		return err
+	}
+
+	defer s.notify()
+
+	if s.fallback {
+		s.snapshots, s.fallback = []models.Rates{rates}, false
+
+		return nil
+	}
+
+	if n := len(s.snapshots); n > 0 && s.snapshots[n-1].Date.Equal(rates.Date) {
+		// a corrected feed of the same date replaces the previous one
+		s.snapshots[n-1] = rates
+
+		return nil
+	}
+
+	s.snapshots = append(s.snapshots, rates)
+
+	return nil
 }
 
-const round = 100
+// notify signals a change without waiting, as a pending signal covers any number of changes.
+func (s *Service) notify() {
+	select {
+	case s.changes <- struct{}{}:
+	default:
+	}
+}
+
+// Changes signals the changes of the current rates.
+func (s *Service) Changes() <-chan struct{} {
+	return s.changes
+}
+
+// snapshot returns the rates that applied at the time, or the current rates for a zero time.
+func (s *Service) snapshot(at time.Time) (models.Rates, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.snapshots) == 0 {
+		return models.Rates{}, models.ErrRatesNotFound
+	}
+
+	if at.IsZero() {
+		return s.snapshots[len(s.snapshots)-1], nil
+	}
+
+	i := sort.Search(len(s.snapshots), func(i int) bool {
+		return s.snapshots[i].Date.After(at)
+	})
+	if i == 0 {
+		return models.Rates{}, models.ErrRatesNotFound
+	}
 
-func (s *Service) GetRate(request models.XRRequest) (float64, error) {
+	return s.snapshots[i-1], nil
+}
+
+func (s *Service) GetRate(request models.XRRequest) (models.XRResponse, error) {
 	timeStart := time.Now()
 	defer func() {
 		s.metrics.externalRequestDuration.WithLabelValues("get_rate").Observe(time.Since(timeStart).Seconds())
 	}()
 
-	exchangeRates := map[string]float64{
-		"USD": 1.5, //nolint:mnd
-		"EUR": 1.6, //nolint:mnd
-		"RUB": 1,
-		"JPY": 0.8, //nolint:mnd
-		"CNY": 1.2, //nolint:mnd
-		"CAD": 1.3, //nolint:mnd
-		"AUD": 1.1, //nolint:mnd
+	rates, err := s.snapshot(request.At)
+	if err != nil {
+		return models.XRResponse{}, fmt.Errorf("failed to get rates at %s: %w", request.At, err)
+	}
+
+	return s.quote(rates, request.FromCurrency, request.ToCurrency, request.At)
+}
+
+// GetRates returns the rates of every currency against the base.
+func (s *Service) GetRates(base string, at time.Time) (models.Rates, error) {
+	timeStart := time.Now()
+	defer func() {
+		s.metrics.externalRequestDuration.WithLabelValues("get_rates").Observe(time.Since(timeStart).Seconds())
+	}()
+
+	rates, err := s.snapshot(at)
+	if err != nil {
+		return models.Rates{}, fmt.Errorf("failed to get rates at %s: %w", at, err)
 	}
 
-	fromRate, fromExist := exchangeRates[strings.ToUpper(request.FromCurrency)]
-	toRate, toExist := exchangeRates[strings.ToUpper(request.ToCurrency)]
+	result := models.Rates{
+		Base:  strings.ToUpper(base),
+		Date:  rates.Date,
+		Rates: make(map[string]float64, len(rates.Rates)),
+	}
+
+	for currency := range rates.Rates {
+		response, err := s.quote(rates, base, currency, at)
+		if err != nil {
+			return models.Rates{}, err
+		}
+
+		result.Rates[currency] = response.Rate
+	}
+
+	return result, nil
+}
+
+// GetRatesTo returns the rates of every currency into the currency, quoted as the pairs into it.
+func (s *Service) GetRatesTo(to string, at time.Time) (models.RatesTo, error) {
+	timeStart := time.Now()
+	defer func() {
+		s.metrics.externalRequestDuration.WithLabelValues("get_rates_to").Observe(time.Since(timeStart).Seconds())
+	}()
+
+	rates, err := s.snapshot(at)
+	if err != nil {
+		return models.RatesTo{}, fmt.Errorf("failed to get rates at %s: %w", at, err)
+	}
+
+	result := models.RatesTo{
+		To:    strings.ToUpper(to),
+		Date:  rates.Date,
+		Rates: make(map[string]float64, len(rates.Rates)),
+	}
+
+	for currency := range rates.Rates {
+		response, err := s.quote(rates, currency, to, at)
+		if err != nil {
+			return models.RatesTo{}, err
+		}
+
+		result.Rates[currency] = response.Rate
+	}
+
+	return result, nil
+}
+
+// GetRateTable returns the current quotes of every pair of the currencies.
+func (s *Service) GetRateTable() (models.RateTable, error) {
+	rates, err := s.snapshot(time.Time{})
+	if err != nil {
+		return models.RateTable{}, fmt.Errorf("failed to get rates: %w", err)
+	}
+
+	table := models.RateTable{
+		Quotes:      make(map[string]models.XRResponse, len(rates.Rates)*len(rates.Rates)),
+		PublishedAt: time.Now().UTC(),
+	}
+
+	for from := range rates.Rates {
+		for to := range rates.Rates {
+			quote, err := s.quote(rates, from, to, time.Time{})
+			if err != nil {
+				return models.RateTable{}, err
+			}
+
+			table.Quotes[models.CurrencyPair(from, to)] = quote
+		}
+	}
+
+	return table, nil
+}
+
+// Convert converts every amount with rates of the same snapshot.
+func (s *Service) Convert(request models.XRConvertRequest) (models.XRConvertResponse, error) {
+	timeStart := time.Now()
+	defer func() {
+		s.metrics.externalRequestDuration.WithLabelValues("convert").Observe(time.Since(timeStart).Seconds())
+	}()
+
+	rates, err := s.snapshot(request.At)
+	if err != nil {
+		return models.XRConvertResponse{}, fmt.Errorf("failed to get rates at %s: %w", request.At, err)
+	}
+
+	response := models.XRConvertResponse{
+		Date:        rates.Date,
+		Conversions: make([]models.Conversion, 0, len(request.Conversions)),
+	}
+
+	for _, conversion := range request.Conversions {
+		quote, err := s.quote(rates, conversion.From, conversion.To, request.At)
+		if err != nil {
+			return models.XRConvertResponse{}, fmt.Errorf("failed to convert %s to %s: %w", conversion.From,
+				conversion.To, err)
+		}
+
+		conversion.Rate = quote.Rate
+		// the result is rounded like the rates of the pair
+		conversion.Result = s.quoting.pair(strings.ToUpper(conversion.From),
+			strings.ToUpper(conversion.To)).round(conversion.Amount * conversion.Rate)
+		response.Conversions = append(response.Conversions, conversion)
+	}
+
+	return response, nil
+}
+
+// quote returns the rates of the pair at the time, or now for a zero time, rounded as configured for
+// the pair. Rates set by hand for the pair, or inverted from the opposite pair, have their own bid and
+// ask. Other pairs are crossed from the snapshot, with the configured spread around the mid rate.
+func (s *Service) quote(rates models.Rates, from, to string, at time.Time) (models.XRResponse, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	quoting := s.quoting.pair(from, to)
+
+	var bid, ask float64
+
+	if override, ok := s.overrides.find(from, to, at); ok {
+		bid, ask = override.Bid, override.Ask
+	} else if override, ok = s.overrides.find(to, from, at); ok {
+		bid, ask = 1/override.Ask, 1/override.Bid
+	} else {
+		mid, err := crossRate(rates, from, to)
+		if err != nil {
+			return models.XRResponse{}, err
+		}
+
+		bid, ask = mid, mid
+
+		// a currency converts to itself at par
+		if from != to {
+			bid, ask = mid*(1-quoting.Spread/2), mid*(1+quoting.Spread/2) //nolint:mnd
+		}
+	}
+
+	return models.XRResponse{
+		Rate:     quoting.round((bid + ask) / 2), //nolint:mnd
+		Bid:      quoting.round(bid),
+		Ask:      quoting.round(ask),
+		Rounding: quoting.rounding(),
+		Date:     rates.Date,
+	}, nil
+}
+
+// SetRateOverride stores a rate of a currency pair set by hand. It applies from EffectiveFrom,
+// or from now when that is not set or has passed.
+func (s *Service) SetRateOverride(override models.RateOverride, actor string) (models.RateOverride, error) {
+	override.From, override.To = strings.ToUpper(override.From), strings.ToUpper(override.To)
+
+	if err := s.validateOverride(override); err != nil {
+		return models.RateOverride{}, err
+	}
+
+	override, err := s.overrides.set(override, actor)
+	if err != nil {
+		return models.RateOverride{}, fmt.Errorf("failed to set rate override: %w", err)
+	}
+
+	s.metrics.overrideChanges.WithLabelValues(models.RateChangeSet).Inc()
+	s.notify()
+
+	return override, nil
+}
+
+// ExpireRateOverride stops a rate set by hand at the time, or now for a zero or past time.
+func (s *Service) ExpireRateOverride(id models.RateOverrideID, at time.Time,
+	actor string,
+) (models.RateOverride, error) {
+	override, err := s.overrides.expire(id, at, actor)
+	if err != nil {
+		return models.RateOverride{}, fmt.Errorf("failed to expire rate override: %w", err)
+	}
+
+	s.metrics.overrideChanges.WithLabelValues(models.RateChangeExpire).Inc()
+	s.notify()
+
+	return override, nil
+}
+
+// GetRateOverrides returns the rates set by hand, including the scheduled and expired ones.
+func (s *Service) GetRateOverrides() []models.RateOverride {
+	return s.overrides.list()
+}
+
+// GetRateChanges returns the changes of the rates set by hand for the pair, or of all pairs.
+func (s *Service) GetRateChanges(from, to string) []models.RateChange {
+	return s.overrides.history(from, to)
+}
+
+func (s *Service) validateOverride(override models.RateOverride) error {
+	s.mu.RLock()
+	latest := s.snapshots[len(s.snapshots)-1]
+	s.mu.RUnlock()
+
+	_, fromExist := latest.Rates[override.From]
+	_, toExist := latest.Rates[override.To]
+
+	start := override.EffectiveFrom
+	if now := time.Now(); start.Before(now) {
+		start = now
+	}
+
+	switch {
+	case !fromExist || !toExist:
+		return fmt.Errorf("currency not found in rates: %w", models.ErrWrongCurrency)
+	case override.From == override.To:
+		return fmt.Errorf("%w: currencies of the pair are the same", models.ErrInvalidRequest)
+	case !valid(override.Bid) || !valid(override.Ask) || override.Bid > override.Ask:
+		return fmt.Errorf("%w: bid and ask must be positive and bid must not exceed ask", models.ErrInvalidRequest)
+	case override.ExpiresAt != nil && !override.ExpiresAt.After(start):
+		return fmt.Errorf("%w: rate override expires before it starts", models.ErrInvalidRequest)
+	}
+
+	return nil
+}
+
+func valid(rate float64) bool {
+	return rate > 0 && !math.IsInf(rate, 0) && !math.IsNaN(rate)
+}
+
+// crossRate returns the mid rate from one currency of the snapshot to another.
+func crossRate(rates models.Rates, from, to string) (float64, error) {
+	fromRate, fromExist := rates.Rates[from]
+	toRate, toExist := rates.Rates[to]
 
 	if !fromExist || !toExist {
-		return 0, fmt.Errorf("currency not found in map: %w", models.ErrWrongCurrency)
+		return 0, fmt.Errorf("currency not found in rates: %w", models.ErrWrongCurrency)
+	}
+
+	return toRate / fromRate, nil
+}
+
+// normalize upper-cases the currencies, adds the base and rejects unusable rates.
+func normalize(rates *models.Rates) error {
+	if rates.Base == "" || len(rates.Rates) == 0 {
+		return errNoRates
 	}
 
-	rate := toRate / fromRate
+	normalized := make(map[string]float64, len(rates.Rates)+1)
+
+	for currency, rate := range rates.Rates {
+		if !valid(rate) {
+			return fmt.Errorf("%w: invalid rate %v of %s", errNoRates, rate, currency)
+		}
+
+		normalized[strings.ToUpper(currency)] = rate
+	}
 
-	roundedRate := math.Ceil(rate*round) / round
+	rates.Base = strings.ToUpper(rates.Base)
+	normalized[rates.Base] = 1
+	rates.Rates = normalized
 
-	return roundedRate, nil
+	return nil
 }
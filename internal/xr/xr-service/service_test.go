@@ -0,0 +1,621 @@
+package xrservice_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	xrservice "github.com/Memonagi/wallet_project/internal/xr/xr-service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+const ecbFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2024-10-28">
+			<Cube currency="USD" rate="1.0808"/>
+			<Cube currency="JPY" rate="165.09"/>
+			<Cube currency="CNY" rate="7.7002"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+var errUnavailable = errors.New("unavailable")
+
+type fakeProvider struct {
+	mu    sync.Mutex
+	rates models.Rates
+	err   error
+	calls int
+}
+
+func (p *fakeProvider) Rates(_ context.Context) (models.Rates, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+
+	return p.rates, p.err
+}
+
+func (p *fakeProvider) set(rates models.Rates, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rates, p.err = rates, err
+}
+
+// history has an old snapshot, corrected by its second line.
+const history = `{"base":"USD","date":"2024-09-01T00:00:00Z","rates":{"USD":1,"EUR":0.5}}
+{"base":"USD","date":"2024-09-01T00:00:00Z","rates":{"USD":1,"EUR":0.8}}
+`
+
+// overrides has a rate set by hand for a day of the history.
+const overrides = `{"action":"set","override":{"id":[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16],"from":"JPY","to":"CNY",` +
+	`"bid":4.9,"ask":5.1,"effectiveFrom":"2024-09-01T00:00:00Z","expiresAt":"2024-09-02T00:00:00Z"},` +
+	`"actor":"treasury","at":"2024-08-31T10:00:00Z"}
+`
+
+type ServiceTestSuite struct {
+	suite.Suite
+	provider      *fakeProvider
+	service       *xrservice.Service
+	historyFile   string
+	overridesFile string
+}
+
+func (s *ServiceTestSuite) SetupSuite() {
+	dir := s.T().TempDir()
+
+	s.historyFile = filepath.Join(dir, "history.jsonl")
+	err := os.WriteFile(s.historyFile, []byte(history), 0o600)
+	s.Require().NoError(err)
+
+	s.overridesFile = filepath.Join(dir, "overrides.jsonl")
+	err = os.WriteFile(s.overridesFile, []byte(overrides), 0o600)
+	s.Require().NoError(err)
+
+	s.provider = &fakeProvider{rates: xrservice.DefaultRates()}
+	s.service, err = xrservice.New(context.Background(), xrservice.Config{
+		HistoryFile:   s.historyFile,
+		OverridesFile: s.overridesFile,
+		Quoting: xrservice.Quoting{Pairs: map[string]xrservice.PairQuoting{
+			"usd/jpy": {Precision: precision(4), Mode: models.RoundingHalfEven},
+			"CNY/*":   {Precision: precision(3), Mode: models.RoundingFloor, Spread: 0.02},
+			"AUD/RUB": {Precision: precision(0), Mode: models.RoundingHalfEven},
+		}},
+	}, s.provider)
+	s.Require().NoError(err)
+}
+
+func (s *ServiceTestSuite) SetupTest() {
+	s.provider.set(xrservice.DefaultRates(), nil)
+
+	err := s.service.Refresh(context.Background())
+	s.Require().NoError(err)
+}
+
+func precision(n int) *int {
+	return &n
+}
+
+func TestServiceSetupSuite(t *testing.T) {
+	suite.Run(t, new(ServiceTestSuite))
+}
+
+func (s *ServiceTestSuite) TestGetRate() {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		rate float64
+		err  error
+	}{
+		{name: "from base", from: "RUB", to: "USD", rate: 1.5},
+		{name: "cross rate", from: "USD", to: "EUR", rate: 1.07},
+		{name: "lower case", from: "rub", to: "eur", rate: 1.6},
+		{name: "unknown currency", from: "RUB", to: "XYZ", err: models.ErrWrongCurrency},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			response, err := s.service.GetRate(models.XRRequest{FromCurrency: tc.from, ToCurrency: tc.to})
+			if tc.err != nil {
+				s.Require().ErrorIs(err, tc.err)
+
+				return
+			}
+
+			s.Require().NoError(err)
+			s.Require().InDelta(tc.rate, response.Rate, 1e-9)
+		})
+	}
+}
+
+func (s *ServiceTestSuite) TestGetRates() {
+	rates, err := s.service.GetRates("usd", time.Time{})
+	s.Require().NoError(err)
+	s.Require().Equal("USD", rates.Base)
+	s.Require().Len(rates.Rates, len(xrservice.DefaultRates().Rates))
+	s.Require().InDelta(1.0, rates.Rates["USD"], 1e-9)
+	s.Require().InDelta(1.07, rates.Rates["EUR"], 1e-9)
+
+	_, err = s.service.GetRates("XYZ", time.Time{})
+	s.Require().ErrorIs(err, models.ErrWrongCurrency)
+}
+
+func (s *ServiceTestSuite) TestGetRatesTo() {
+	rates, err := s.service.GetRatesTo("eur", time.Time{})
+	s.Require().NoError(err)
+	s.Require().Equal("EUR", rates.To)
+	s.Require().Len(rates.Rates, len(xrservice.DefaultRates().Rates))
+	s.Require().InDelta(1.0, rates.Rates["EUR"], 1e-9)
+	s.Require().InDelta(1.07, rates.Rates["USD"], 1e-9)
+	s.Require().InDelta(1.6, rates.Rates["RUB"], 1e-9)
+
+	_, err = s.service.GetRatesTo("XYZ", time.Time{})
+	s.Require().ErrorIs(err, models.ErrWrongCurrency)
+}
+
+func (s *ServiceTestSuite) TestConvert() {
+	response, err := s.service.Convert(models.XRConvertRequest{Conversions: []models.Conversion{
+		{From: "RUB", To: "USD", Amount: 100},
+		{From: "USD", To: "EUR", Amount: 10.5},
+		{From: "AUD", To: "RUB", Amount: 10.4},
+	}})
+	s.Require().NoError(err)
+	s.Require().Len(response.Conversions, 3)
+	s.Require().InDelta(1.5, response.Conversions[0].Rate, 1e-9)
+	s.Require().InDelta(150, response.Conversions[0].Result, 1e-9)
+	s.Require().InDelta(11.24, response.Conversions[1].Result, 1e-9)
+	s.Require().InDelta(10, response.Conversions[2].Result, 1e-9)
+
+	_, err = s.service.Convert(models.XRConvertRequest{Conversions: []models.Conversion{
+		{From: "RUB", To: "XYZ", Amount: 1},
+	}})
+	s.Require().ErrorIs(err, models.ErrWrongCurrency)
+}
+
+func (s *ServiceTestSuite) TestQuoting() {
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		rate     float64
+		bid      float64
+		ask      float64
+		rounding models.Rounding
+	}{
+		{name: "default", from: "USD", to: "EUR", rate: 1.07, bid: 1.07, ask: 1.07,
+			rounding: models.Rounding{Precision: 2, Mode: models.RoundingCeil}},
+		{name: "no float error", from: "RUB", to: "AUD", rate: 1.1, bid: 1.1, ask: 1.1,
+			rounding: models.Rounding{Precision: 2, Mode: models.RoundingCeil}},
+		{name: "pair", from: "USD", to: "JPY", rate: 0.5333, bid: 0.5333, ask: 0.5333,
+			rounding: models.Rounding{Precision: 4, Mode: models.RoundingHalfEven}},
+		{name: "any currency with spread", from: "CNY", to: "EUR", rate: 1.333, bid: 1.32, ask: 1.346,
+			rounding: models.Rounding{Precision: 3, Mode: models.RoundingFloor}},
+		{name: "same currency at par", from: "CNY", to: "CNY", rate: 1, bid: 1, ask: 1,
+			rounding: models.Rounding{Precision: 3, Mode: models.RoundingFloor}},
+		{name: "whole units", from: "AUD", to: "RUB", rate: 1, bid: 1, ask: 1,
+			rounding: models.Rounding{Precision: 0, Mode: models.RoundingHalfEven}},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			response, err := s.service.GetRate(models.XRRequest{FromCurrency: tc.from, ToCurrency: tc.to})
+			s.Require().NoError(err)
+			s.Require().InDelta(tc.rate, response.Rate, 1e-9)
+			s.Require().InDelta(tc.bid, response.Bid, 1e-9)
+			s.Require().InDelta(tc.ask, response.Ask, 1e-9)
+			s.Require().Equal(tc.rounding, response.Rounding)
+		})
+	}
+}
+
+func (s *ServiceTestSuite) TestLoadQuoting() {
+	path := filepath.Join(s.T().TempDir(), "quoting.json")
+	err := os.WriteFile(path, []byte(`{"default":{"precision":4},"pairs":{"JPY/USD":{"mode":"half_even"},`+
+		`"USD/JPY":{"precision":0}}}`), 0o600)
+	s.Require().NoError(err)
+
+	quoting, err := xrservice.LoadQuoting(path)
+	s.Require().NoError(err)
+	s.Require().Equal(precision(4), quoting.Default.Precision)
+	s.Require().Equal(models.RoundingHalfEven, quoting.Pairs["JPY/USD"].Mode)
+	s.Require().Nil(quoting.Pairs["JPY/USD"].Precision)
+	s.Require().Equal(precision(0), quoting.Pairs["USD/JPY"].Precision)
+}
+
+func (s *ServiceTestSuite) TestRatesAreNotReloadedPerRequest() {
+	s.provider.mu.Lock()
+	calls := s.provider.calls
+	s.provider.mu.Unlock()
+
+	for range 10 {
+		_, err := s.service.GetRate(models.XRRequest{FromCurrency: "RUB", ToCurrency: "USD"})
+		s.Require().NoError(err)
+	}
+
+	s.provider.mu.Lock()
+	s.Require().Equal(calls, s.provider.calls)
+	s.provider.mu.Unlock()
+}
+
+func (s *ServiceTestSuite) TestRefresh() {
+	s.provider.set(models.Rates{Base: "USD", Rates: map[string]float64{"EUR": 0.5}}, nil)
+
+	err := s.service.Refresh(context.Background())
+	s.Require().NoError(err)
+
+	response, err := s.service.GetRate(models.XRRequest{FromCurrency: "EUR", ToCurrency: "USD"})
+	s.Require().NoError(err)
+	s.Require().InDelta(2.0, response.Rate, 1e-9)
+
+	s.Run("failed refresh keeps the rates", func() {
+		s.provider.set(models.Rates{}, errUnavailable)
+
+		err := s.service.Refresh(context.Background())
+		s.Require().ErrorIs(err, errUnavailable)
+
+		_, err = s.service.GetRate(models.XRRequest{FromCurrency: "EUR", ToCurrency: "USD"})
+		s.Require().NoError(err)
+	})
+
+	s.Run("invalid rates are rejected", func() {
+		s.provider.set(models.Rates{Base: "USD", Rates: map[string]float64{"EUR": 0}}, nil)
+
+		err := s.service.Refresh(context.Background())
+		s.Require().Error(err)
+
+		_, err = s.service.GetRate(models.XRRequest{FromCurrency: "EUR", ToCurrency: "USD"})
+		s.Require().NoError(err)
+	})
+}
+
+func (s *ServiceTestSuite) TestHistory() {
+	// the suite has undated snapshots from now on, so these are dated later
+	start := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	hour := func(h int) time.Time {
+		return start.Add(time.Duration(h) * time.Hour)
+	}
+
+	s.provider.set(models.Rates{Base: "USD", Date: hour(1), Rates: map[string]float64{"EUR": 0.5}}, nil)
+	s.Require().NoError(s.service.Refresh(context.Background()))
+
+	s.provider.set(models.Rates{Base: "USD", Date: hour(3), Rates: map[string]float64{"EUR": 0.25}}, nil)
+	s.Require().NoError(s.service.Refresh(context.Background()))
+
+	tests := []struct {
+		name string
+		at   time.Time
+		rate float64
+		date time.Time
+	}{
+		{name: "current", rate: 4, date: hour(3)},
+		{name: "start of a snapshot", at: hour(1), rate: 2, date: hour(1)},
+		{name: "between snapshots", at: hour(2), rate: 2, date: hour(1)},
+		{name: "after the latest snapshot", at: hour(20), rate: 4, date: hour(3)},
+		{name: "from the history file", at: time.Date(2024, 9, 2, 0, 0, 0, 0, time.UTC), rate: 1.25,
+			date: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		s.Run(tc.name, func() {
+			response, err := s.service.GetRate(models.XRRequest{FromCurrency: "EUR", ToCurrency: "USD", At: tc.at})
+			s.Require().NoError(err)
+			s.Require().InDelta(tc.rate, response.Rate, 1e-9)
+			s.Require().True(tc.date.Equal(response.Date), "date %s, want %s", response.Date, tc.date)
+		})
+	}
+
+	s.Run("older feed is ignored", func() {
+		s.provider.set(models.Rates{Base: "USD", Date: hour(2), Rates: map[string]float64{"EUR": 0.1}}, nil)
+		s.Require().NoError(s.service.Refresh(context.Background()))
+
+		response, err := s.service.GetRate(models.XRRequest{FromCurrency: "EUR", ToCurrency: "USD", At: hour(2)})
+		s.Require().NoError(err)
+		s.Require().InDelta(2.0, response.Rate, 1e-9)
+	})
+
+	s.Run("before the first snapshot", func() {
+		at := time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC)
+
+		_, err := s.service.GetRate(models.XRRequest{FromCurrency: "EUR", ToCurrency: "USD", At: at})
+		s.Require().ErrorIs(err, models.ErrRatesNotFound)
+	})
+
+	s.Run("changes are appended to the file", func() {
+		data, err := os.ReadFile(s.historyFile)
+		s.Require().NoError(err)
+		s.Require().Contains(string(data), hour(3).Format(time.RFC3339))
+	})
+}
+
+func (s *ServiceTestSuite) TestRateOverrides() {
+	override, err := s.service.SetRateOverride(models.RateOverride{From: "cad", To: "aud", Bid: 0.9, Ask: 1.1},
+		"treasury")
+	s.Require().NoError(err)
+	s.Require().Equal("CAD", override.From)
+	s.Require().WithinDuration(time.Now(), override.EffectiveFrom, time.Second)
+
+	scheduled, err := s.service.SetRateOverride(models.RateOverride{
+		From: "CAD", To: "AUD", Bid: 2, Ask: 2, EffectiveFrom: time.Now().Add(10 * time.Minute),
+	}, "treasury")
+	s.Require().NoError(err)
+
+	s.Run("rate set by hand is the midpoint", func() {
+		response, err := s.service.GetRate(models.XRRequest{FromCurrency: "CAD", ToCurrency: "AUD"})
+		s.Require().NoError(err)
+		s.Require().InDelta(1.0, response.Rate, 1e-9)
+		s.Require().InDelta(0.9, response.Bid, 1e-9)
+		s.Require().InDelta(1.1, response.Ask, 1e-9)
+	})
+
+	s.Run("opposite pair is inverted", func() {
+		response, err := s.service.GetRate(models.XRRequest{FromCurrency: "AUD", ToCurrency: "CAD"})
+		s.Require().NoError(err)
+		s.Require().InDelta(1.02, response.Rate, 1e-9)
+		s.Require().InDelta(0.91, response.Bid, 1e-9)
+		s.Require().InDelta(1.12, response.Ask, 1e-9)
+	})
+
+	s.Run("scheduled rate applies from its start", func() {
+		response, err := s.service.GetRate(models.XRRequest{
+			FromCurrency: "CAD", ToCurrency: "AUD", At: time.Now().Add(20 * time.Minute),
+		})
+		s.Require().NoError(err)
+		s.Require().InDelta(2.0, response.Rate, 1e-9)
+	})
+
+	s.Run("expired rate falls back to the provider rates", func() {
+		_, err := s.service.ExpireRateOverride(override.ID, time.Time{}, "treasury")
+		s.Require().NoError(err)
+
+		response, err := s.service.GetRate(models.XRRequest{FromCurrency: "CAD", ToCurrency: "AUD"})
+		s.Require().NoError(err)
+		s.Require().InDelta(0.85, response.Rate, 1e-9)
+		s.Require().InDelta(0.85, response.Bid, 1e-9)
+
+		_, err = s.service.ExpireRateOverride(override.ID, time.Time{}, "treasury")
+		s.Require().ErrorIs(err, models.ErrInvalidRequest)
+	})
+
+	s.Run("scheduled rate expired before its start never applies", func() {
+		expired, err := s.service.ExpireRateOverride(scheduled.ID, time.Time{}, "treasury")
+		s.Require().NoError(err)
+		s.Require().Equal(scheduled.EffectiveFrom, *expired.ExpiresAt)
+
+		response, err := s.service.GetRate(models.XRRequest{
+			FromCurrency: "CAD", ToCurrency: "AUD", At: time.Now().Add(20 * time.Minute),
+		})
+		s.Require().NoError(err)
+		s.Require().InDelta(0.85, response.Bid, 1e-9)
+	})
+
+	s.Run("unknown override", func() {
+		_, err := s.service.ExpireRateOverride(models.RateOverrideID(uuid.New()), time.Time{}, "treasury")
+		s.Require().ErrorIs(err, models.ErrRateOverrideNotFound)
+	})
+
+	s.Run("invalid overrides", func() {
+		tests := []struct {
+			name     string
+			override models.RateOverride
+			err      error
+		}{
+			{name: "unknown currency", override: models.RateOverride{From: "CAD", To: "XYZ", Bid: 1, Ask: 1},
+				err: models.ErrWrongCurrency},
+			{name: "same currencies", override: models.RateOverride{From: "CAD", To: "CAD", Bid: 1, Ask: 1},
+				err: models.ErrInvalidRequest},
+			{name: "bid above ask", override: models.RateOverride{From: "CAD", To: "AUD", Bid: 2, Ask: 1},
+				err: models.ErrInvalidRequest},
+			{name: "no ask", override: models.RateOverride{From: "CAD", To: "AUD", Bid: 1},
+				err: models.ErrInvalidRequest},
+		}
+
+		for _, tc := range tests {
+			s.Run(tc.name, func() {
+				_, err := s.service.SetRateOverride(tc.override, "treasury")
+				s.Require().ErrorIs(err, tc.err)
+			})
+		}
+	})
+
+	s.Run("changes are audited and stored", func() {
+		changes := s.service.GetRateChanges("aud", "cad")
+		s.Require().Len(changes, 4)
+		s.Require().Equal(models.RateChangeSet, changes[0].Action)
+		s.Require().Equal(models.RateChangeExpire, changes[2].Action)
+		s.Require().Equal("treasury", changes[2].Actor)
+
+		data, err := os.ReadFile(s.overridesFile)
+		s.Require().NoError(err)
+		s.Require().Len(strings.Split(strings.TrimSpace(string(data)), "\n"), 5)
+	})
+}
+
+func (s *ServiceTestSuite) TestRateOverridesFile() {
+	s.Require().NotEmpty(s.service.GetRateOverrides())
+	s.Require().Len(s.service.GetRateChanges("JPY", ""), 1)
+
+	// the stored rate applied on its day only
+	response, err := s.service.GetRate(models.XRRequest{
+		FromCurrency: "JPY", ToCurrency: "CNY", At: time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC),
+	})
+	s.Require().NoError(err)
+	s.Require().InDelta(5.0, response.Rate, 1e-9)
+
+	response, err = s.service.GetRate(models.XRRequest{FromCurrency: "JPY", ToCurrency: "CNY"})
+	s.Require().NoError(err)
+	s.Require().InDelta(1.5, response.Rate, 1e-9)
+}
+
+func (s *ServiceTestSuite) TestECBProvider() {
+	path := filepath.Join(s.T().TempDir(), "eurofxref-daily.xml")
+	err := os.WriteFile(path, []byte(ecbFeed), 0o600)
+	s.Require().NoError(err)
+
+	check := func(provider xrservice.Provider) {
+		rates, err := provider.Rates(context.Background())
+		s.Require().NoError(err)
+		s.Require().Equal("EUR", rates.Base)
+		s.Require().Equal(time.Date(2024, 10, 28, 0, 0, 0, 0, time.UTC), rates.Date)
+		s.Require().InDelta(1.0808, rates.Rates["USD"], 1e-9)
+		s.Require().InDelta(165.09, rates.Rates["JPY"], 1e-9)
+	}
+
+	s.Run("file", func() {
+		check(xrservice.NewECB(path))
+	})
+
+	s.Run("url", func() {
+		feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(ecbFeed))
+		}))
+		defer feedServer.Close()
+
+		check(xrservice.NewECB(feedServer.URL))
+	})
+
+	s.Run("unavailable url", func() {
+		feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer feedServer.Close()
+
+		_, err := xrservice.NewECB(feedServer.URL).Rates(context.Background())
+		s.Require().Error(err)
+	})
+}
+
+func (s *ServiceTestSuite) TestStaticFileProvider() {
+	path := filepath.Join(s.T().TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte(`{"base":"USD","rates":{"EUR":0.92}}`), 0o600)
+	s.Require().NoError(err)
+
+	rates, err := xrservice.NewStaticFile(path).Rates(context.Background())
+	s.Require().NoError(err)
+	s.Require().Equal("USD", rates.Base)
+	s.Require().InDelta(0.92, rates.Rates["EUR"], 1e-9)
+
+	_, err = xrservice.NewStaticFile(filepath.Join(s.T().TempDir(), "missing.json")).Rates(context.Background())
+	s.Require().Error(err)
+}
+
+func (s *ServiceTestSuite) TestChainProvider() {
+	failing := &fakeProvider{err: errUnavailable}
+	fallback := xrservice.NewStatic(models.Rates{Base: "USD", Rates: map[string]float64{"EUR": 0.92}})
+
+	rates, err := xrservice.NewChain(failing, fallback).Rates(context.Background())
+	s.Require().NoError(err)
+	s.Require().Equal("USD", rates.Base)
+
+	_, err = xrservice.NewChain(failing, failing).Rates(context.Background())
+	s.Require().ErrorIs(err, errUnavailable)
+
+	_, err = xrservice.NewChain().Rates(context.Background())
+	s.Require().Error(err)
+	s.Run("every call starts with the first provider", func() {
+		primary := &fakeProvider{rates: models.Rates{Base: "EUR"}}
+		chain := xrservice.NewChain(primary, fallback)
+
+		primary.set(models.Rates{}, errUnavailable)
+
+		rates, err := chain.Rates(context.Background())
+		s.Require().NoError(err)
+		s.Require().Equal("USD", rates.Base)
+
+		primary.set(models.Rates{Base: "EUR"}, nil)
+
+		rates, err = chain.Rates(context.Background())
+		s.Require().NoError(err)
+		s.Require().Equal("EUR", rates.Base)
+	})
+}
+
+func (s *ServiceTestSuite) TestGetRateTable() {
+	table, err := s.service.GetRateTable()
+	s.Require().NoError(err)
+	s.Require().Len(table.Quotes, len(xrservice.DefaultRates().Rates)*len(xrservice.DefaultRates().Rates))
+	s.Require().WithinDuration(time.Now(), table.PublishedAt, time.Minute)
+	s.Require().InDelta(1.5, table.Quotes["RUB/USD"].Bid, 1e-9)
+	s.Require().InDelta(1.32, table.Quotes["CNY/EUR"].Bid, 1e-9)
+	s.Require().InDelta(1.346, table.Quotes["CNY/EUR"].Ask, 1e-9)
+}
+
+type fakeProducer struct {
+	mu     sync.Mutex
+	values []string
+}
+
+func (p *fakeProducer) ProduceRates(_ context.Context, _, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.values = append(p.values, value)
+
+	return nil
+}
+
+func (p *fakeProducer) last() (models.RateTable, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.values) == 0 {
+		return models.RateTable{}, false
+	}
+
+	var table models.RateTable
+	if err := json.Unmarshal([]byte(p.values[len(p.values)-1]), &table); err != nil {
+		return models.RateTable{}, false
+	}
+
+	return table, true
+}
+
+func (s *ServiceTestSuite) TestPublisher() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	producer := &fakeProducer{}
+	publisher := xrservice.NewPublisher(xrservice.PublisherConfig{Interval: time.Hour}, s.service, producer)
+
+	done := make(chan error)
+
+	go func() {
+		done <- publisher.Run(ctx)
+	}()
+
+	s.Require().Eventually(func() bool {
+		table, ok := producer.last()
+
+		return ok && table.Quotes["RUB/USD"].Rate == 1.5
+	}, time.Second, 10*time.Millisecond)
+
+	rates := xrservice.DefaultRates()
+	rates.Rates["USD"] = 2
+
+	s.provider.set(rates, nil)
+	err := s.service.Refresh(ctx)
+	s.Require().NoError(err)
+
+	s.Require().Eventually(func() bool {
+		table, ok := producer.last()
+
+		return ok && table.Quotes["RUB/USD"].Rate == 2
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	s.Require().NoError(<-done)
+}
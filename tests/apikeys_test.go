@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const apiKeysPath = `/api/v1/admin/api-keys`
+
+func (s *IntegrationTestSuite) TestAPIKeys() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	wallet := models.Wallet{
+		UserID:   existingUser.UserID,
+		Name:     "proverkaAPIKEY",
+		Currency: "RUB",
+	}
+	createdWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, &createdWallet, existingUser)
+
+	walletIDPath := walletPath + "/" + uuid.UUID(createdWallet.WalletID).String()
+	transaction := models.Transaction{
+		FirstWalletID: createdWallet.WalletID,
+		Money:         100.0,
+		Currency:      "RUB",
+	}
+	admin := "Bearer " + s.signToken(jwtclaims.Claims{UserID: models.UserID(uuid.New()), Role: models.RoleAdmin})
+
+	s.Run("admin role required", func() {
+		apiKey := models.APIKey{Name: "payouts", Scopes: []string{models.ScopeWalletsDeposit}}
+
+		// Act
+		s.sendRequest(http.MethodPost, apiKeysPath, http.StatusForbidden, &apiKey, nil, existingUser)
+	})
+
+	s.Run("invalid scope", func() {
+		apiKey := models.APIKey{Name: "payouts", Scopes: []string{models.ScopeAdmin}}
+
+		// Act
+		s.sendRequestWithHeader(http.MethodPost, apiKeysPath, http.StatusBadRequest, &apiKey, nil,
+			"Authorization", admin)
+	})
+
+	s.Run("deposit-only key across users", func() {
+		apiKey := models.APIKey{Name: "payouts", Scopes: []string{models.ScopeWalletsDeposit}}
+		createdKey := models.APIKey{}
+
+		s.sendRequestWithHeader(http.MethodPost, apiKeysPath, http.StatusCreated, &apiKey, &createdKey,
+			"Authorization", admin)
+		s.Require().NotEmpty(createdKey.Key)
+		s.Require().Equal(createdKey.Prefix, createdKey.Key[:len(createdKey.Prefix)])
+
+		// Act
+		s.sendRequestWithHeader(http.MethodPut, walletIDPath+"/deposit", http.StatusOK, &transaction, nil,
+			"X-API-Key", createdKey.Key)
+		s.sendRequestWithHeader(http.MethodPut, walletIDPath+"/withdraw", http.StatusForbidden, &transaction, nil,
+			"X-API-Key", createdKey.Key)
+		s.sendRequestWithHeader(http.MethodGet, walletIDPath, http.StatusForbidden, nil, nil,
+			"X-API-Key", createdKey.Key)
+
+		// Assert
+		gotWallet := models.Wallet{}
+		s.sendRequest(http.MethodGet, walletIDPath, http.StatusOK, nil, &gotWallet, existingUser)
+		s.Require().InDelta(100.0, gotWallet.Balance, 0.001)
+	})
+
+	s.Run("read-only key of a user", func() {
+		apiKey := models.APIKey{Name: "reports", UserID: &existingUser.UserID, Scopes: []string{models.ScopeWalletsRead}}
+		createdKey := models.APIKey{}
+
+		s.sendRequestWithHeader(http.MethodPost, apiKeysPath, http.StatusCreated, &apiKey, &createdKey,
+			"Authorization", admin)
+
+		// Act
+		gotWallet := models.Wallet{}
+		s.sendRequestWithHeader(http.MethodGet, walletIDPath, http.StatusOK, nil, &gotWallet,
+			"X-API-Key", createdKey.Key)
+		s.sendRequestWithHeader(http.MethodPut, walletIDPath+"/deposit", http.StatusForbidden, &transaction, nil,
+			"X-API-Key", createdKey.Key)
+
+		// Assert
+		s.Require().Equal(createdWallet.WalletID, gotWallet.WalletID)
+	})
+
+	s.Run("deleted and expired keys", func() {
+		expiresAt := time.Now().Add(time.Second)
+		apiKey := models.APIKey{Name: "temporary", Scopes: []string{models.ScopeWalletsDeposit}, ExpiresAt: &expiresAt}
+		createdKey := models.APIKey{}
+
+		s.sendRequestWithHeader(http.MethodPost, apiKeysPath, http.StatusCreated, &apiKey, &createdKey,
+			"Authorization", admin)
+
+		time.Sleep(time.Until(expiresAt))
+
+		// Act
+		s.sendRequestWithHeader(http.MethodPut, walletIDPath+"/deposit", http.StatusUnauthorized, &transaction, nil,
+			"X-API-Key", createdKey.Key)
+		s.sendRequestWithHeader(http.MethodDelete, apiKeysPath+"/"+uuid.UUID(createdKey.ID).String(), http.StatusOK,
+			nil, nil, "Authorization", admin)
+		s.sendRequestWithHeader(http.MethodDelete, apiKeysPath+"/"+uuid.UUID(createdKey.ID).String(),
+			http.StatusNotFound, nil, nil, "Authorization", admin)
+
+		// Assert
+		apiKeys := []models.APIKey{}
+		s.sendRequestWithHeader(http.MethodGet, apiKeysPath, http.StatusOK, nil, &apiKeys, "Authorization", admin)
+		s.Require().Len(apiKeys, 2)
+		s.Require().Empty(apiKeys[0].Key)
+	})
+
+	s.Run("scoped token", func() {
+		token := "Bearer " + s.signToken(jwtclaims.Claims{UserID: existingUser.UserID, Scope: models.ScopeWalletsRead})
+
+		// Act
+		s.sendRequestWithHeader(http.MethodGet, walletIDPath, http.StatusOK, nil, nil, "Authorization", token)
+		s.sendRequestWithHeader(http.MethodPut, walletIDPath+"/withdraw", http.StatusForbidden, &transaction, nil,
+			"Authorization", token)
+	})
+}
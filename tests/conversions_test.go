@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+func (s *IntegrationTestSuite) TestConvertedTransactions() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	wallet := models.Wallet{Name: "proverkaCONVERT", Currency: "RUB", UserID: existingUser.UserID}
+	createdWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, &createdWallet, existingUser)
+
+	uuidString := uuid.UUID(createdWallet.WalletID).String()
+
+	s.Run("deposit in another currency without convert", func() {
+		transaction := models.Transaction{FirstWalletID: createdWallet.WalletID, Money: 100, Currency: "USD"}
+
+		// Act
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/deposit", http.StatusBadRequest, &transaction,
+			nil, existingUser)
+	})
+
+	s.Run("deposit is converted at the bid less the fee", func() {
+		transaction := models.Transaction{
+			FirstWalletID: createdWallet.WalletID,
+			Money:         100,
+			Currency:      "USD",
+			Convert:       true,
+		}
+
+		// Act
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/deposit", http.StatusOK, &transaction, nil,
+			existingUser)
+
+		// Assert
+		var updated models.Wallet
+
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuidString, http.StatusOK, nil, &updated, existingUser)
+		s.Require().InDelta(66.33, updated.Balance, 1e-9)
+	})
+
+	s.Run("withdrawal is converted at the ask plus the fee", func() {
+		transaction := models.Transaction{
+			FirstWalletID: createdWallet.WalletID,
+			Money:         10,
+			Currency:      "USD",
+			Convert:       true,
+		}
+
+		// Act
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/withdraw", http.StatusOK, &transaction, nil,
+			existingUser)
+
+		// Assert
+		var updated models.Wallet
+
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuidString, http.StatusOK, nil, &updated, existingUser)
+		s.Require().InDelta(59.563, updated.Balance, 1e-9)
+	})
+
+	s.Run("withdrawal above the balance", func() {
+		transaction := models.Transaction{
+			FirstWalletID: createdWallet.WalletID,
+			Money:         100,
+			Currency:      "USD",
+			Convert:       true,
+		}
+
+		// Act
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/withdraw", http.StatusBadRequest, &transaction,
+			nil, existingUser)
+	})
+
+	s.Run("transaction in the wallet currency is not converted", func() {
+		transaction := models.Transaction{
+			FirstWalletID: createdWallet.WalletID,
+			Money:         10,
+			Currency:      "RUB",
+			Convert:       true,
+		}
+
+		// Act
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/deposit", http.StatusOK, &transaction, nil,
+			existingUser)
+	})
+
+	s.Run("conversions are recorded", func() {
+		var transactions []models.Transaction
+
+		// Act
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuidString+"/transactions?sorting=created_at", http.StatusOK,
+			nil, &transactions, existingUser)
+
+		// Assert
+		s.Require().Len(transactions, 3)
+
+		deposit := transactions[0]
+		s.Require().Equal(models.EventDeposit, deposit.Name)
+		s.Require().Equal("USD", deposit.Currency)
+		s.Require().InDelta(100, deposit.Money, 1e-9)
+		s.Require().NotNil(deposit.Conversion)
+		s.Require().Equal("RUB", deposit.Conversion.WalletCurrency)
+		s.Require().InDelta(66.33, deposit.Conversion.WalletMoney, 1e-9)
+		s.Require().InDelta(0.67, deposit.Conversion.Rate, 1e-9)
+		s.Require().InDelta(0.67, deposit.Conversion.MidRate, 1e-9)
+		s.Require().InDelta(0.67, deposit.Conversion.Fee, 1e-9)
+
+		withdrawal := transactions[1]
+		s.Require().Equal(models.EventWithdraw, withdrawal.Name)
+		s.Require().NotNil(withdrawal.Conversion)
+		s.Require().InDelta(6.767, withdrawal.Conversion.WalletMoney, 1e-9)
+		s.Require().InDelta(0.067, withdrawal.Conversion.Fee, 1e-9)
+
+		s.Require().Nil(transactions[2].Conversion)
+
+		var rate models.TransactionRate
+
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuidString+"/transactions/"+uuid.UUID(deposit.ID).String()+
+			"/rate", http.StatusOK, nil, &rate, existingUser)
+		s.Require().Equal("USD", rate.FromCurrency)
+		s.Require().Equal("RUB", rate.ToCurrency)
+		s.Require().InDelta(0.67, rate.Rate, 1e-9)
+	})
+}
@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/grpc-server/walletpb"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/totp"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *IntegrationTestSuite) TestGRPCWallets() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	client := s.grpcClient()
+	ctx := s.grpcContext(existingUser)
+
+	s.Run("unauthenticated", func() {
+		// Act
+		_, err := client.GetWallets(context.Background(), &walletpb.ListRequest{})
+
+		// Assert
+		s.Require().Equal(codes.Unauthenticated, status.Code(err))
+	})
+
+	s.Run("create and get wallet", func() {
+		// Act
+		created, err := client.CreateWallet(ctx, &walletpb.CreateWalletRequest{Name: "proverkaGRPC", Currency: "RUB"})
+		s.Require().NoError(err)
+
+		wallet, err := client.GetWallet(ctx, &walletpb.WalletRequest{WalletId: created.GetWalletId()})
+
+		// Assert
+		s.Require().NoError(err)
+		s.Require().Equal("proverkaGRPC", wallet.GetName())
+		s.Require().Equal(uuid.UUID(existingUser.UserID).String(), wallet.GetUserId())
+	})
+
+	s.Run("wallet not found", func() {
+		// Act
+		_, err := client.GetWallet(ctx, &walletpb.WalletRequest{WalletId: uuid.New().String()})
+
+		// Assert
+		s.Require().Equal(codes.NotFound, status.Code(err))
+	})
+
+	s.Run("invalid wallet id", func() {
+		// Act
+		_, err := client.GetWallet(ctx, &walletpb.WalletRequest{WalletId: "proverka"})
+
+		// Assert
+		s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	})
+
+	s.Run("profile", func() {
+		// Act
+		profile, err := client.GetProfile(ctx, &emptypb.Empty{})
+
+		// Assert
+		s.Require().NoError(err)
+		s.Require().Equal(uuid.UUID(existingUser.UserID).String(), profile.GetUser().GetUserId())
+	})
+}
+
+func (s *IntegrationTestSuite) TestGRPCTransactions() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	client := s.grpcClient()
+	ctx := s.grpcContext(existingUser)
+
+	wallet, err := client.CreateWallet(ctx, &walletpb.CreateWalletRequest{Name: "proverkaGRPC", Currency: "RUB"})
+	s.Require().NoError(err)
+
+	const deposits = 5
+
+	for range deposits {
+		_, err = client.Deposit(ctx, &walletpb.TransactionRequest{
+			FirstWalletId: wallet.GetWalletId(),
+			Money:         100,
+			Currency:      "RUB",
+		})
+		s.Require().NoError(err)
+	}
+
+	s.Run("insufficient funds", func() {
+		// Act
+		_, err := client.Withdraw(ctx, &walletpb.TransactionRequest{
+			FirstWalletId: wallet.GetWalletId(),
+			Money:         1000,
+			Currency:      "RUB",
+		})
+
+		// Assert
+		s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+	})
+
+	s.Run("stream whole history", func() {
+		// Act
+		stream, err := client.GetTransactions(ctx, &walletpb.GetTransactionsRequest{
+			WalletId: wallet.GetWalletId(),
+			List:     &walletpb.ListRequest{Limit: 2},
+		})
+		s.Require().NoError(err)
+
+		var received []*walletpb.Transaction
+
+		for {
+			transaction, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			s.Require().NoError(err)
+
+			received = append(received, transaction)
+		}
+
+		// Assert
+		s.Require().Len(received, deposits)
+	})
+}
+
+func (s *IntegrationTestSuite) TestGRPCStepUp() {
+	// Arrange
+	user := models.User{UserID: models.UserID(uuid.New())}
+
+	err := s.db.UpsertUser(context.Background(), user)
+	s.Require().NoError(err)
+
+	enrollment := models.TOTPEnrollment{}
+
+	s.sendRequest(http.MethodPost, totpPath, http.StatusCreated, nil, &enrollment, user)
+	s.sendRequest(http.MethodPost, totpPath+"/confirm", http.StatusOK,
+		&models.TOTPCode{Code: s.totpCode(enrollment.Secret, time.Now())}, nil, user)
+
+	client := s.grpcClient()
+	ctx := s.grpcContext(user)
+
+	wallet, err := client.CreateWallet(ctx, &walletpb.CreateWalletRequest{Name: "proverkaGRPC_STEPUP", Currency: "RUB"})
+	s.Require().NoError(err)
+
+	_, err = client.Deposit(ctx, &walletpb.TransactionRequest{
+		FirstWalletId: wallet.GetWalletId(),
+		Money:         20000,
+		Currency:      "RUB",
+	})
+	s.Require().NoError(err)
+
+	var operation *walletpb.PendingOperation
+
+	s.Run("withdrawal requires confirmation", func() {
+		// Act
+		_, err := client.Withdraw(ctx, &walletpb.TransactionRequest{
+			FirstWalletId: wallet.GetWalletId(),
+			Money:         8000,
+			Currency:      "RUB",
+		})
+
+		// Assert
+		st := status.Convert(err)
+		s.Require().Equal(codes.FailedPrecondition, st.Code())
+		s.Require().Len(st.Details(), 1)
+
+		var ok bool
+
+		operation, ok = st.Details()[0].(*walletpb.PendingOperation)
+		s.Require().True(ok)
+		s.Require().Equal(models.EventWithdraw, operation.GetType())
+	})
+
+	s.Run("wrong code", func() {
+		// Act
+		_, err := client.ConfirmOperation(ctx, &walletpb.ConfirmOperationRequest{
+			OperationId: operation.GetId(),
+			Code:        "000000",
+		})
+
+		// Assert
+		s.Require().Equal(codes.PermissionDenied, status.Code(err))
+	})
+
+	s.Run("confirm withdrawal", func() {
+		// Act
+		_, err := client.ConfirmOperation(ctx, &walletpb.ConfirmOperationRequest{
+			OperationId: operation.GetId(),
+			Code:        s.totpCode(enrollment.Secret, time.Now().Add(totp.Period)),
+		})
+
+		// Assert
+		s.Require().NoError(err)
+
+		updated, err := client.GetWallet(ctx, &walletpb.WalletRequest{WalletId: wallet.GetWalletId()})
+		s.Require().NoError(err)
+		s.Require().InDelta(12000.0, updated.GetBalance(), 0.001)
+	})
+}
+
+func (s *IntegrationTestSuite) grpcClient() walletpb.WalletServiceClient {
+	conn, err := grpc.NewClient(fmt.Sprintf("localhost:%d", grpcPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	s.Require().NoError(err)
+
+	s.T().Cleanup(func() {
+		err := conn.Close()
+		s.Require().NoError(err)
+	})
+
+	return walletpb.NewWalletServiceClient(conn)
+}
+
+func (s *IntegrationTestSuite) grpcContext(user models.User) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+s.getToken(user))
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+func (s *IntegrationTestSuite) TestHealth() {
+	s.Run("liveness", func() {
+		// Act
+		code, _ := s.probe("/healthz")
+
+		// Assert
+		s.Require().Equal(http.StatusOK, code)
+	})
+
+	s.Run("readiness", func() {
+		// Act
+		code, readiness := s.probe("/readyz")
+
+		// Assert
+		s.Require().Equal(http.StatusOK, code)
+		s.Require().Equal(models.HealthOK, readiness.Status)
+		s.Require().Equal(models.HealthOK, readiness.Checks["postgres"].Status)
+		s.Require().Equal(models.HealthOK, readiness.Checks["migrations"].Status)
+	})
+}
+
+func (s *IntegrationTestSuite) probe(path string) (int, models.Readiness) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+		fmt.Sprintf("http://localhost:%d%s", port, path), nil)
+	s.Require().NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+
+	defer func() {
+		err = resp.Body.Close()
+		s.Require().NoError(err)
+	}()
+
+	var readiness models.Readiness
+
+	err = json.NewDecoder(resp.Body).Decode(&readiness)
+	s.Require().NoError(err)
+
+	return resp.StatusCode, readiness
+}
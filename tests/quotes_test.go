@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const quotesPath = `/api/v1/quotes`
+
+func (s *IntegrationTestSuite) TestQuotes() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	secondUser := models.User{
+		UserID: models.UserID(uuid.New()),
+	}
+
+	err = s.db.UpsertUser(context.Background(), secondUser)
+	s.Require().NoError(err)
+
+	firstWallet := models.Wallet{Name: "proverkaQUOTE_1", Currency: "RUB", UserID: existingUser.UserID}
+	secondWallet := models.Wallet{Name: "proverkaQUOTE_2", Currency: "USD", UserID: secondUser.UserID}
+	firstCreatedWallet := models.Wallet{}
+	secondCreatedWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &firstWallet, &firstCreatedWallet, existingUser)
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &secondWallet, &secondCreatedWallet, secondUser)
+
+	uuidString := uuid.UUID(firstCreatedWallet.WalletID).String()
+	transferPath := walletPath + "/" + uuidString + "/transfer"
+
+	deposit := models.Transaction{
+		FirstWalletID: firstCreatedWallet.WalletID,
+		Money:         1000.0,
+		Currency:      "RUB",
+	}
+
+	s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/deposit", http.StatusOK, &deposit, nil, existingUser)
+
+	transfer := func(quoteID models.QuoteID) models.Transaction {
+		return models.Transaction{
+			FirstWalletID:  firstCreatedWallet.WalletID,
+			SecondWalletID: &secondCreatedWallet.WalletID,
+			Money:          100.0,
+			Currency:       "RUB",
+			QuoteID:        &quoteID,
+		}
+	}
+
+	var quote models.Quote
+
+	s.Run("invalid currency", func() {
+		var problem models.Problem
+
+		// Act
+		s.sendRequest(http.MethodPost, quotesPath, http.StatusBadRequest,
+			&models.QuoteRequest{FromCurrency: "RUB", ToCurrency: "XYZ"}, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal("currency_invalid", problem.Code)
+	})
+
+	s.Run("quote locks the rate", func() {
+		// Act
+		s.sendRequest(http.MethodPost, quotesPath, http.StatusCreated,
+			&models.QuoteRequest{FromCurrency: "rub", ToCurrency: "USD"}, &quote, existingUser)
+
+		// Assert
+		s.Require().Equal("RUB", quote.FromCurrency)
+		s.Require().Equal("USD", quote.ToCurrency)
+		s.Require().InDelta(1.5, quote.Rate, 1e-9)
+		s.Require().True(quote.ExpiresAt.After(time.Now()))
+	})
+
+	s.Run("transfer at the quoted rate", func() {
+		tx := transfer(quote.ID)
+
+		// Act
+		s.sendRequest(http.MethodPut, transferPath, http.StatusOK, &tx, nil, existingUser)
+
+		// Assert
+		var wallet models.Wallet
+
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuid.UUID(secondCreatedWallet.WalletID).String(), http.StatusOK,
+			nil, &wallet, secondUser)
+		s.Require().InDelta(150.0, wallet.Balance, 1e-9)
+	})
+
+	s.Run("quote is used once", func() {
+		var problem models.Problem
+
+		tx := transfer(quote.ID)
+
+		// Act
+		s.sendRequest(http.MethodPut, transferPath, http.StatusConflict, &tx, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal("quote_used", problem.Code)
+	})
+
+	s.Run("quote of another pair", func() {
+		var (
+			other   models.Quote
+			problem models.Problem
+		)
+
+		s.sendRequest(http.MethodPost, quotesPath, http.StatusCreated,
+			&models.QuoteRequest{FromCurrency: "RUB", ToCurrency: "EUR"}, &other, existingUser)
+
+		tx := transfer(other.ID)
+
+		// Act
+		s.sendRequest(http.MethodPut, transferPath, http.StatusBadRequest, &tx, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal("quote_mismatch", problem.Code)
+	})
+
+	s.Run("quote of another user", func() {
+		var (
+			other   models.Quote
+			problem models.Problem
+		)
+
+		s.sendRequest(http.MethodPost, quotesPath, http.StatusCreated,
+			&models.QuoteRequest{FromCurrency: "RUB", ToCurrency: "USD"}, &other, secondUser)
+
+		tx := transfer(other.ID)
+
+		// Act
+		s.sendRequest(http.MethodPut, transferPath, http.StatusNotFound, &tx, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal("quote_not_found", problem.Code)
+	})
+
+	s.Run("expired quote", func() {
+		var problem models.Problem
+
+		expired, err := s.db.CreateQuote(context.Background(), models.Quote{
+			ID:           models.QuoteID(uuid.New()),
+			UserID:       existingUser.UserID,
+			FromCurrency: "RUB",
+			ToCurrency:   "USD",
+			Rate:         1.5,
+			ExpiresAt:    time.Now().Add(-time.Second),
+		})
+		s.Require().NoError(err)
+
+		tx := transfer(expired.ID)
+
+		// Act
+		s.sendRequest(http.MethodPut, transferPath, http.StatusConflict, &tx, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal("quote_expired", problem.Code)
+	})
+
+	s.Run("quote is kept until the confirmation deadline", func() {
+		var kept models.Quote
+
+		s.sendRequest(http.MethodPost, quotesPath, http.StatusCreated,
+			&models.QuoteRequest{FromCurrency: "RUB", ToCurrency: "USD"}, &kept, existingUser)
+
+		deadline := time.Now().Add(5 * time.Minute)
+
+		// Act
+		err := s.db.ExtendQuote(context.Background(), kept.ID, existingUser.UserID, deadline)
+
+		// Assert
+		s.Require().NoError(err)
+
+		extended, err := s.db.GetQuote(context.Background(), kept.ID, existingUser.UserID)
+		s.Require().NoError(err)
+		s.Require().WithinDuration(deadline, extended.ExpiresAt, time.Millisecond)
+	})
+
+	s.Run("used quote is not extended", func() {
+		// Act
+		err := s.db.ExtendQuote(context.Background(), quote.ID, existingUser.UserID, time.Now().Add(time.Minute))
+
+		// Assert
+		s.Require().ErrorIs(err, models.ErrQuoteExpired)
+	})
+}
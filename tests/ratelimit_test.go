@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"context"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+func (s *IntegrationTestSuite) TestTakeRateLimitToken() {
+	limit := models.RateLimit{Rate: 0.1, Burst: 2}
+
+	s.Run("burst exhausted", func() {
+		// Act
+		first, _, err := s.db.TakeRateLimitToken(context.Background(), "write:user", limit)
+		s.Require().NoError(err)
+
+		second, _, err := s.db.TakeRateLimitToken(context.Background(), "write:user", limit)
+		s.Require().NoError(err)
+
+		third, tokens, err := s.db.TakeRateLimitToken(context.Background(), "write:user", limit)
+		s.Require().NoError(err)
+
+		// Assert
+		s.Require().True(first)
+		s.Require().True(second)
+		s.Require().False(third)
+		s.Require().Less(tokens, 1.0)
+	})
+
+	s.Run("refilled after cleanup", func() {
+		err := s.db.DeleteRateLimits(context.Background(), time.Now().Add(time.Hour))
+		s.Require().NoError(err)
+
+		// Act
+		allowed, tokens, err := s.db.TakeRateLimitToken(context.Background(), "write:user", limit)
+
+		// Assert
+		s.Require().NoError(err)
+		s.Require().True(allowed)
+		s.Require().InDelta(1.0, tokens, 0.01)
+	})
+}
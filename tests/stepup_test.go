@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/Memonagi/wallet_project/internal/totp"
+	"github.com/google/uuid"
+)
+
+const (
+	totpPath       = `/api/v1/me/totp`
+	operationsPath = `/api/v1/operations`
+)
+
+func (s *IntegrationTestSuite) TestStepUp() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	wallet := models.Wallet{
+		UserID:   existingUser.UserID,
+		Name:     "proverkaSTEPUP",
+		Currency: "RUB",
+	}
+	createdWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, &createdWallet, existingUser)
+
+	walletIDPath := walletPath + "/" + uuid.UUID(createdWallet.WalletID).String()
+	deposit := models.Transaction{FirstWalletID: createdWallet.WalletID, Money: 20000.0, Currency: "RUB"}
+
+	s.sendRequest(http.MethodPut, walletIDPath+"/deposit", http.StatusOK, &deposit, nil, existingUser)
+
+	// 8000 RUB is 12000 USD in the test exchange rates.
+	withdraw := models.Transaction{FirstWalletID: createdWallet.WalletID, Money: 8000.0, Currency: "RUB"}
+
+	s.Run("not enrolled", func() {
+		problem := models.Problem{}
+
+		s.sendRequest(http.MethodPut, walletIDPath+"/withdraw", http.StatusForbidden, &withdraw, &problem,
+			existingUser)
+		s.Require().Equal("totp_not_enrolled", problem.Code)
+	})
+
+	s.Run("below threshold", func() {
+		small := models.Transaction{FirstWalletID: createdWallet.WalletID, Money: 1000.0, Currency: "RUB"}
+
+		s.sendRequest(http.MethodPut, walletIDPath+"/withdraw", http.StatusOK, &small, nil, existingUser)
+	})
+
+	enrollment := models.TOTPEnrollment{}
+
+	s.sendRequest(http.MethodPost, totpPath, http.StatusCreated, nil, &enrollment, existingUser)
+	s.Require().NotEmpty(enrollment.Secret)
+
+	s.Run("enroll with wrong code", func() {
+		s.sendRequest(http.MethodPost, totpPath+"/confirm", http.StatusForbidden, &models.TOTPCode{Code: "000000"},
+			nil, existingUser)
+	})
+
+	code := s.totpCode(enrollment.Secret, time.Now())
+
+	s.sendRequest(http.MethodPost, totpPath+"/confirm", http.StatusOK, &models.TOTPCode{Code: code}, nil,
+		existingUser)
+
+	s.Run("enroll again", func() {
+		s.sendRequest(http.MethodPost, totpPath, http.StatusConflict, nil, nil, existingUser)
+	})
+
+	s.Run("confirm withdrawal", func() {
+		problem := models.Problem{}
+
+		s.sendRequest(http.MethodPut, walletIDPath+"/withdraw", http.StatusForbidden, &withdraw, &problem,
+			existingUser)
+		s.Require().Equal("confirmation_required", problem.Code)
+		s.Require().NotNil(problem.Operation)
+		s.Require().Equal(models.EventWithdraw, problem.Operation.Type)
+
+		confirmPath := operationsPath + "/" + uuid.UUID(problem.Operation.ID).String() + "/confirm"
+
+		// the code used to enroll can't be used again
+		s.sendRequest(http.MethodPost, confirmPath, http.StatusForbidden, &models.TOTPCode{Code: code}, nil,
+			existingUser)
+
+		next := s.totpCode(enrollment.Secret, time.Now().Add(totp.Period))
+
+		s.sendRequest(http.MethodPost, confirmPath, http.StatusOK, &models.TOTPCode{Code: next}, nil, existingUser)
+
+		walletInfo := models.Wallet{}
+
+		s.sendRequest(http.MethodGet, walletIDPath, http.StatusOK, nil, &walletInfo, existingUser)
+		s.Require().InDelta(11000.0, walletInfo.Balance, 0.001)
+
+		s.sendRequest(http.MethodPost, confirmPath, http.StatusNotFound, &models.TOTPCode{Code: next}, nil,
+			existingUser)
+	})
+
+	s.Run("operation of another user", func() {
+		problem := models.Problem{}
+
+		s.sendRequest(http.MethodPut, walletIDPath+"/withdraw", http.StatusForbidden, &withdraw, &problem,
+			existingUser)
+		s.Require().NotNil(problem.Operation)
+
+		confirmPath := operationsPath + "/" + uuid.UUID(problem.Operation.ID).String() + "/confirm"
+
+		s.sendRequest(http.MethodPost, confirmPath, http.StatusNotFound, &models.TOTPCode{Code: "000000"}, nil,
+			models.User{UserID: models.UserID(uuid.New())})
+	})
+
+	s.Run("too many attempts", func() {
+		problem := models.Problem{}
+
+		s.sendRequest(http.MethodPut, walletIDPath+"/withdraw", http.StatusForbidden, &withdraw, &problem,
+			existingUser)
+		s.Require().NotNil(problem.Operation)
+
+		confirmPath := operationsPath + "/" + uuid.UUID(problem.Operation.ID).String() + "/confirm"
+
+		for range 5 {
+			s.sendRequest(http.MethodPost, confirmPath, http.StatusForbidden, &models.TOTPCode{Code: "000000"}, nil,
+				existingUser)
+		}
+
+		valid := s.totpCode(enrollment.Secret, time.Now().Add(totp.Period))
+
+		s.sendRequest(http.MethodPost, confirmPath, http.StatusTooManyRequests, &models.TOTPCode{Code: valid}, nil,
+			existingUser)
+	})
+}
+
+func (s *IntegrationTestSuite) totpCode(secret string, t time.Time) string {
+	code, err := totp.Code(secret, t)
+	s.Require().NoError(err)
+
+	return code
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const streamPath = walletPath + "/stream"
+
+type streamEvent struct {
+	id        string
+	eventType string
+}
+
+func (s *IntegrationTestSuite) TestStreamWalletEvents() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	wallet := models.Wallet{
+		UserID:   existingUser.UserID,
+		Name:     "proverkaSTREAM",
+		Currency: "RUB",
+	}
+	createdWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, &createdWallet, existingUser)
+
+	depositPath := walletPath + "/" + uuid.UUID(createdWallet.WalletID).String() + "/deposit"
+	transaction := models.Transaction{
+		FirstWalletID: createdWallet.WalletID,
+		Money:         1000.0,
+		Currency:      "RUB",
+	}
+
+	var lastSeq int64
+
+	s.Run("receive new events", func() {
+		events := s.openStream("")
+
+		// Act
+		s.sendRequest(http.MethodPut, depositPath, http.StatusOK, &transaction, nil, existingUser)
+
+		// Assert
+		received := s.readEvents(events, 2)
+		s.Require().ElementsMatch([]string{models.WalletEventBalance, models.WalletEventTransaction},
+			[]string{received[0].eventType, received[1].eventType})
+
+		lastSeq = s.eventSeq(received[1])
+		s.Require().Greater(lastSeq, s.eventSeq(received[0]))
+	})
+
+	s.Run("resume after last event id", func() {
+		s.sendRequest(http.MethodPut, depositPath, http.StatusOK, &transaction, nil, existingUser)
+
+		// Act
+		events := s.openStream(strconv.FormatInt(lastSeq, 10))
+
+		// Assert
+		received := s.readEvents(events, 2)
+		s.Require().Greater(s.eventSeq(received[0]), lastSeq)
+		s.Require().Greater(s.eventSeq(received[1]), s.eventSeq(received[0]))
+	})
+}
+
+func (s *IntegrationTestSuite) openStream(lastEventID string) <-chan streamEvent {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.T().Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://localhost:%d%s", port, streamPath), nil)
+	s.Require().NoError(err)
+
+	req.Header.Set("Authorization", "Bearer "+s.getToken(existingUser))
+
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	events := make(chan streamEvent)
+
+	go func() {
+		defer resp.Body.Close()
+
+		var event streamEvent
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.eventType = strings.TrimPrefix(line, "event: ")
+			case line == "" && event.id != "":
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+
+				event = streamEvent{}
+			}
+		}
+	}()
+
+	return events
+}
+
+func (s *IntegrationTestSuite) eventSeq(event streamEvent) int64 {
+	seq, err := strconv.ParseInt(event.id, 10, 64)
+	s.Require().NoError(err)
+
+	return seq
+}
+
+func (s *IntegrationTestSuite) readEvents(events <-chan streamEvent, count int) []streamEvent {
+	received := make([]streamEvent, 0, count)
+
+	for range count {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(3 * time.Second):
+			s.FailNow("timed out waiting for wallet event")
+		}
+	}
+
+	return received
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const tokensPath = `/api/v1/tokens`
+
+func (s *IntegrationTestSuite) TestRevokeTokens() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	s.Run("revoke single token", func() {
+		token := s.getToken(existingUser)
+		other := s.getToken(existingUser)
+		revocation := models.TokenRevocation{Token: token}
+
+		// Act
+		s.sendRequestWithHeader(http.MethodPost, tokensPath+"/revoke", http.StatusOK, &revocation, nil,
+			"Authorization", "Bearer "+other)
+
+		// Assert
+		s.sendRequestWithHeader(http.MethodGet, walletPath, http.StatusUnauthorized, nil, nil,
+			"Authorization", "Bearer "+token)
+		s.sendRequestWithHeader(http.MethodGet, walletPath, http.StatusOK, nil, nil,
+			"Authorization", "Bearer "+other)
+	})
+
+	s.Run("token of another user", func() {
+		revocation := models.TokenRevocation{Token: s.signToken(jwtclaims.Claims{UserID: models.UserID(uuid.New())})}
+
+		// Act
+		s.sendRequest(http.MethodPost, tokensPath+"/revoke", http.StatusForbidden, &revocation, nil, existingUser)
+	})
+
+	s.Run("read-only token can't revoke", func() {
+		token := "Bearer " + s.signToken(jwtclaims.Claims{UserID: existingUser.UserID, Scope: models.ScopeWalletsRead})
+		revocation := models.TokenRevocation{Before: time.Now()}
+
+		// Act
+		s.sendRequestWithHeader(http.MethodPost, tokensPath+"/revoke-all", http.StatusForbidden, &revocation, nil,
+			"Authorization", token)
+	})
+
+	s.Run("revoke all tokens issued before", func() {
+		token := s.getToken(existingUser)
+
+		time.Sleep(time.Second)
+
+		revocation := models.TokenRevocation{Before: time.Now()}
+
+		// Act
+		s.sendRequest(http.MethodPost, tokensPath+"/revoke-all", http.StatusOK, &revocation, nil, existingUser)
+
+		// Assert
+		s.sendRequestWithHeader(http.MethodGet, walletPath, http.StatusUnauthorized, nil, nil,
+			"Authorization", "Bearer "+token)
+
+		time.Sleep(time.Second)
+		s.sendRequest(http.MethodGet, walletPath, http.StatusOK, nil, nil, existingUser)
+	})
+}
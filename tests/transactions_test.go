@@ -3,6 +3,7 @@ package tests
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/Memonagi/wallet_project/internal/models"
 	"github.com/google/uuid"
@@ -635,3 +636,128 @@ func (s *IntegrationTestSuite) TestGetTransactions() {
 		s.sendRequest(http.MethodGet, txPath, http.StatusNotFound, nil, nil, userFromAnotherMother)
 	})
 }
+
+func (s *IntegrationTestSuite) TestGetTransactionRate() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	secondUser := models.User{
+		UserID: models.UserID(uuid.New()),
+	}
+
+	err = s.db.UpsertUser(context.Background(), secondUser)
+	s.Require().NoError(err)
+
+	firstWallet := models.Wallet{Name: "proverkaTX_RATE_1", Currency: "RUB", UserID: existingUser.UserID}
+	secondWallet := models.Wallet{Name: "proverkaTX_RATE_2", Currency: "USD", UserID: secondUser.UserID}
+	firstCreatedWallet := models.Wallet{}
+	secondCreatedWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &firstWallet, &firstCreatedWallet, existingUser)
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &secondWallet, &secondCreatedWallet, secondUser)
+
+	uuidString := uuid.UUID(firstCreatedWallet.WalletID).String()
+
+	deposit := models.Transaction{
+		ID:            models.TxID(uuid.New()),
+		FirstWalletID: firstCreatedWallet.WalletID,
+		Money:         1000.0,
+		Currency:      "RUB",
+	}
+
+	s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/deposit", http.StatusOK, &deposit, nil, existingUser)
+
+	transfer := models.Transaction{
+		ID:             models.TxID(uuid.New()),
+		FirstWalletID:  firstCreatedWallet.WalletID,
+		SecondWalletID: &secondCreatedWallet.WalletID,
+		Money:          100.0,
+		Currency:       "RUB",
+	}
+
+	s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/transfer", http.StatusOK, &transfer, nil, existingUser)
+
+	var transactions []models.Transaction
+
+	s.sendRequest(http.MethodGet, walletPath+"/"+uuidString+"/transactions?sorting=name", http.StatusOK, nil,
+		&transactions, existingUser)
+	s.Require().Len(transactions, 2)
+
+	ratePath := func(tx models.Transaction) string {
+		return walletPath + "/" + uuidString + "/transactions/" + uuid.UUID(tx.ID).String() + "/rate"
+	}
+
+	s.Run("transfer is converted at the rate it was applied at", func() {
+		var rate models.TransactionRate
+
+		// Act
+		s.sendRequest(http.MethodGet, ratePath(transactions[1]), http.StatusOK, nil, &rate, existingUser)
+
+		// Assert
+		s.Require().NotNil(transactions[1].Rate)
+		s.Require().InDelta(1.5, *transactions[1].Rate, 1e-9)
+		s.Require().Equal(transactions[1].ID, rate.TransactionID)
+		s.Require().Equal("RUB", rate.FromCurrency)
+		s.Require().Equal("USD", rate.ToCurrency)
+		s.Require().InDelta(1.5, rate.Rate, 1e-9)
+		s.Require().WithinDuration(transactions[1].CreatedAt, rate.At, time.Second)
+	})
+
+	s.Run("deposit is not converted", func() {
+		var rate models.TransactionRate
+
+		// Act
+		s.sendRequest(http.MethodGet, ratePath(transactions[0]), http.StatusOK, nil, &rate, existingUser)
+
+		// Assert
+		s.Require().Equal("RUB", rate.ToCurrency)
+		s.Require().InDelta(1.0, rate.Rate, 1e-9)
+	})
+
+	s.Run("transaction not found", func() {
+		missing := models.Transaction{ID: models.TxID(uuid.New())}
+
+		// Act
+		s.sendRequest(http.MethodGet, ratePath(missing), http.StatusNotFound, nil, nil, existingUser)
+	})
+
+	s.Run("user is not the owner of the wallet", func() {
+		// Act
+		s.sendRequest(http.MethodGet, ratePath(transactions[1]), http.StatusNotFound, nil, nil, secondUser)
+	})
+
+	s.Run("transfer in the same currency is not converted", func() {
+		sameWallet := models.Wallet{Name: "proverkaTX_RATE_3", Currency: "rub", UserID: secondUser.UserID}
+		sameCreatedWallet := models.Wallet{}
+
+		s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &sameWallet, &sameCreatedWallet, secondUser)
+
+		same := models.Transaction{
+			ID:             models.TxID(uuid.New()),
+			FirstWalletID:  firstCreatedWallet.WalletID,
+			SecondWalletID: &sameCreatedWallet.WalletID,
+			Money:          100.0,
+			Currency:       "RUB",
+		}
+
+		// Act
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuidString+"/transfer", http.StatusOK, &same, nil, existingUser)
+
+		// Assert
+		var wallet models.Wallet
+
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuid.UUID(sameCreatedWallet.WalletID).String(), http.StatusOK,
+			nil, &wallet, secondUser)
+		s.Require().InDelta(100.0, wallet.Balance, 1e-9)
+
+		var received []models.Transaction
+
+		s.sendRequest(http.MethodGet, walletPath+"/"+uuid.UUID(sameCreatedWallet.WalletID).String()+"/transactions",
+			http.StatusOK, nil, &received, secondUser)
+		s.Require().Len(received, 1)
+		s.Require().NotNil(received[0].Rate)
+		s.Require().InDelta(1.0, *received[0].Rate, 1e-9)
+	})
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const profilePath = `/api/v1/me`
+
+func (s *IntegrationTestSuite) TestGetProfile() {
+	// Arrange
+	user := models.User{
+		UserID:           models.UserID(uuid.New()),
+		UserName:         "Pupa",
+		UserSurname:      "Lupa",
+		UserAge:          30,
+		UserGender:       "male",
+		UserEmail:        "pupa@lupa.com",
+		Country:          "Russia",
+		EngagementSource: "referral",
+		Status:           "active",
+		CreatedAt:        time.Now().Add(-time.Hour),
+		UpdatedAt:        time.Now(),
+	}
+
+	err := s.db.UpsertUser(context.Background(), user)
+	s.Require().NoError(err)
+
+	wallets := []models.Wallet{
+		{UserID: user.UserID, Name: "first", Currency: "RUB"},
+		{UserID: user.UserID, Name: "second", Currency: "RUB"},
+		{UserID: user.UserID, Name: "third", Currency: "USD"},
+	}
+
+	for _, wallet := range wallets {
+		s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, nil, user)
+	}
+
+	s.Run("get profile successfully", func() {
+		profile := models.Profile{}
+
+		// Act
+		s.sendRequest(http.MethodGet, profilePath, http.StatusOK, nil, &profile, user)
+
+		// Assert
+		s.Require().Equal(user.UserID, profile.User.UserID)
+		s.Require().Equal(user.UserName, profile.User.UserName)
+		s.Require().Equal(user.UserEmail, profile.User.UserEmail)
+		s.Require().Equal(user.Country, profile.User.Country)
+		s.Require().Equal(user.UserAge, profile.User.UserAge)
+		s.Require().Equal(3, profile.Wallets.Count)
+		s.Require().Len(profile.Wallets.Balances, 2)
+	})
+
+	s.Run("stale update is ignored", func() {
+		staleUser := user
+		staleUser.UserEmail = "old@lupa.com"
+		staleUser.UpdatedAt = user.UpdatedAt.Add(-time.Minute)
+
+		err = s.db.UpsertUser(context.Background(), staleUser)
+		s.Require().NoError(err)
+
+		profile := models.Profile{}
+
+		// Act
+		s.sendRequest(http.MethodGet, profilePath, http.StatusOK, nil, &profile, user)
+
+		// Assert
+		s.Require().Equal(user.UserEmail, profile.User.UserEmail)
+	})
+
+	s.Run("existing user is not overwritten on create", func() {
+		created, err := s.db.CreateUser(context.Background(), models.User{
+			UserID:    user.UserID,
+			UserEmail: "new@lupa.com",
+			Status:    "blocked",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+		s.Require().NoError(err)
+		s.Require().False(created)
+
+		profile := models.Profile{}
+
+		// Act
+		s.sendRequest(http.MethodGet, profilePath, http.StatusOK, nil, &profile, user)
+
+		// Assert
+		s.Require().Equal(user.UserEmail, profile.User.UserEmail)
+		s.Require().Equal(user.UserName, profile.User.UserName)
+	})
+
+	s.Run("user not found", func() {
+		newUser := models.User{
+			UserID: models.UserID(uuid.New()),
+		}
+
+		// Act
+		s.sendRequest(http.MethodGet, profilePath, http.StatusNotFound, nil, nil, newUser)
+	})
+}
+
+func (s *IntegrationTestSuite) TestUpsertUserOutOfOrder() {
+	// Arrange
+	ctx := context.Background()
+	userID := models.UserID(uuid.New())
+	createdAt := time.Now().Add(-24 * time.Hour).Truncate(time.Microsecond)
+	updates := make([]models.User, 0, 20)
+
+	for i := range 20 {
+		updates = append(updates, models.User{
+			UserID:    userID,
+			UserEmail: fmt.Sprintf("user%d@mail.com", i),
+			Status:    []string{"active", "inactive"}[i%2],
+			Archived:  i%3 == 0,
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	latest := updates[len(updates)-1]
+
+	//nolint:gosec
+	rand.Shuffle(len(updates), func(i, j int) {
+		updates[i], updates[j] = updates[j], updates[i]
+	})
+
+	replayed := append([]models.User{}, updates[:len(updates)/2]...)
+
+	// every update older than one applied before it is skipped, and so is every replay
+	stale := len(replayed)
+	newest := time.Time{}
+
+	for _, update := range updates {
+		if update.UpdatedAt.Before(newest) {
+			stale++
+		} else {
+			newest = update.UpdatedAt
+		}
+	}
+
+	skippedBefore := s.staleUserUpdates()
+
+	// Act
+	for _, update := range updates {
+		err := s.db.UpsertUser(ctx, update)
+		s.Require().NoError(err)
+	}
+
+	for _, update := range replayed {
+		err := s.db.UpsertUser(ctx, update)
+		s.Require().NoError(err)
+	}
+
+	// Assert
+	user, err := s.db.GetUser(ctx, userID)
+	s.Require().NoError(err)
+
+	s.Require().Equal(latest.UserEmail, user.UserEmail)
+	s.Require().Equal(latest.Status, user.Status)
+	s.Require().Equal(latest.Archived, user.Archived)
+	s.Require().True(latest.UpdatedAt.Equal(user.UpdatedAt))
+	s.Require().InDelta(float64(stale), s.staleUserUpdates()-skippedBefore, 1e-9)
+}
+
+func (s *IntegrationTestSuite) staleUserUpdates() float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	s.Require().NoError(err)
+
+	for _, family := range families {
+		if family.GetName() == "wallet_service_database_stale_user_updates_total" {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+
+	return 0
+}
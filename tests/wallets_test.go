@@ -2,6 +2,7 @@ package tests
 
 import (
 	"context"
+	"math"
 	"net/http"
 
 	"github.com/Memonagi/wallet_project/internal/models"
@@ -19,7 +20,24 @@ func (s *IntegrationTestSuite) TestCreateWallet() {
 
 	s.Run("user not found", func() {
 		// Act
-		s.sendRequest(http.MethodPost, walletPath, http.StatusNotFound, &wallet, nil, existingUser)
+		s.sendRequest(http.MethodPost, walletPath, http.StatusForbidden, &wallet, nil, existingUser)
+	})
+
+	s.Run("invalid fields", func() {
+		invalid := models.Wallet{UserID: existingUser.UserID, Currency: "ABC"}
+		problem := models.Problem{}
+
+		// Act
+		s.sendRequest(http.MethodPost, walletPath, http.StatusBadRequest, &invalid, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal(models.CodeValidationFailed, problem.Code)
+		s.Require().Equal(http.StatusBadRequest, problem.Status)
+		s.Require().Equal(walletPath, problem.Instance)
+		s.Require().NotEmpty(problem.RequestID)
+		s.Require().Len(problem.Errors, 2)
+		s.Require().Equal("name", problem.Errors[0].Field)
+		s.Require().Equal("currency", problem.Errors[1].Field)
 	})
 
 	s.Run("created successfully", func() {
@@ -50,9 +68,13 @@ func (s *IntegrationTestSuite) TestCreateWallet() {
 		s.Require().NoError(err)
 
 		wallet.UserID = userFromAnotherMother.UserID
+		problem := models.Problem{}
 
 		// Act
-		s.sendRequest(http.MethodPost, walletPath, http.StatusNotFound, &wallet, nil, existingUser)
+		s.sendRequest(http.MethodPost, walletPath, http.StatusForbidden, &wallet, &problem, existingUser)
+
+		// Assert
+		s.Require().Equal(models.ErrorCode(models.ErrWrongUserID), problem.Code)
 	})
 }
 
@@ -401,3 +423,100 @@ func (s *IntegrationTestSuite) TestGetWallets() {
 // TODO regexp
 // TODO data race task
 // TODO asynchrony: worker pull
+
+func (s *IntegrationTestSuite) TestGetNetWorth() {
+	// Arrange
+	err := s.db.UpsertUser(context.Background(), existingUser)
+	s.Require().NoError(err)
+
+	secondUser := models.User{
+		UserID: models.UserID(uuid.New()),
+	}
+
+	err = s.db.UpsertUser(context.Background(), secondUser)
+	s.Require().NoError(err)
+
+	balances := []struct {
+		user     models.User
+		currency string
+		money    float64
+	}{
+		{user: existingUser, currency: "RUB", money: 100},
+		{user: existingUser, currency: "USD", money: 30},
+		{user: secondUser, currency: "EUR", money: 1000},
+	}
+
+	for _, b := range balances {
+		wallet := models.Wallet{Name: "proverkaNET_WORTH", Currency: b.currency, UserID: b.user.UserID}
+		createdWallet := models.Wallet{}
+
+		s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, &createdWallet, b.user)
+
+		deposit := models.Transaction{FirstWalletID: createdWallet.WalletID, Money: b.money, Currency: b.currency}
+
+		s.sendRequest(http.MethodPut, walletPath+"/"+uuid.UUID(createdWallet.WalletID).String()+"/deposit",
+			http.StatusOK, &deposit, nil, b.user)
+	}
+
+	s.Run("total in the requested currency", func() {
+		var netWorth models.NetWorth
+
+		// Act
+		s.sendRequest(http.MethodGet, walletPath+"/summary?currency=usd", http.StatusOK, nil, &netWorth,
+			existingUser)
+
+		// Assert
+		s.Require().Equal("USD", netWorth.Currency)
+		s.Require().InDelta(180, netWorth.Total, 1e-9)
+		s.Require().Len(netWorth.Wallets, 2)
+		s.Require().Equal("RUB", netWorth.Wallets[0].Currency)
+		s.Require().InDelta(100, netWorth.Wallets[0].Balance, 1e-9)
+		s.Require().InDelta(150, netWorth.Wallets[0].Converted, 1e-9)
+		s.Require().InDelta(30, netWorth.Wallets[1].Converted, 1e-9)
+		s.Require().Len(netWorth.Rates, 1)
+		s.Require().Equal("RUB", netWorth.Rates[0].FromCurrency)
+		s.Require().Equal("USD", netWorth.Rates[0].ToCurrency)
+		s.Require().InDelta(1.5, netWorth.Rates[0].Rate, 1e-9)
+		s.Require().False(netWorth.Rates[0].FetchedAt.IsZero())
+	})
+
+	s.Run("amounts are rounded to the currency", func() {
+		var netWorth models.NetWorth
+
+		// Act
+		s.sendRequest(http.MethodGet, walletPath+"/summary?currency=JPY", http.StatusOK, nil, &netWorth,
+			existingUser)
+
+		// Assert
+		s.Require().Positive(netWorth.Total)
+		s.Require().Equal(math.Round(netWorth.Total), netWorth.Total)
+
+		for _, wallet := range netWorth.Wallets {
+			s.Require().Equal(math.Round(wallet.Converted), wallet.Converted)
+		}
+	})
+
+	s.Run("user without wallets", func() {
+		newUser := models.User{
+			UserID: models.UserID(uuid.New()),
+		}
+
+		err = s.db.UpsertUser(context.Background(), newUser)
+		s.Require().NoError(err)
+
+		var netWorth models.NetWorth
+
+		// Act
+		s.sendRequest(http.MethodGet, walletPath+"/summary?currency=EUR", http.StatusOK, nil, &netWorth, newUser)
+
+		// Assert
+		s.Require().Zero(netWorth.Total)
+		s.Require().Empty(netWorth.Wallets)
+	})
+
+	s.Run("wrong currency", func() {
+		// Act
+		s.sendRequest(http.MethodGet, walletPath+"/summary?currency=XYZ", http.StatusBadRequest, nil, nil,
+			existingUser)
+	})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const webhookPath = `/api/v1/webhooks`
+
+func (s *IntegrationTestSuite) TestWebhooks() {
+	// Arrange
+	ctx := context.Background()
+
+	err := s.db.UpsertUser(ctx, existingUser)
+	s.Require().NoError(err)
+
+	wallet := models.Wallet{
+		UserID:   existingUser.UserID,
+		Name:     "proverkaWEBHOOK",
+		Currency: "RUB",
+	}
+	createdWallet := models.Wallet{}
+
+	s.sendRequest(http.MethodPost, walletPath, http.StatusCreated, &wallet, &createdWallet, existingUser)
+
+	webhook := models.Webhook{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{models.EventDeposit, models.EventWalletArchived},
+	}
+	createdWebhook := models.Webhook{}
+
+	s.Run("wrong url", func() {
+		invalid := webhook
+		invalid.URL = "http://partner.example.com/hooks"
+
+		// Act
+		s.sendRequest(http.MethodPost, webhookPath, http.StatusBadRequest, &invalid, nil, existingUser)
+	})
+
+	s.Run("wrong event type", func() {
+		invalid := webhook
+		invalid.EventTypes = []string{"refund"}
+
+		// Act
+		s.sendRequest(http.MethodPost, webhookPath, http.StatusBadRequest, &invalid, nil, existingUser)
+	})
+
+	s.Run("created successfully", func() {
+		// Act
+		s.sendRequest(http.MethodPost, webhookPath, http.StatusCreated, &webhook, &createdWebhook, existingUser)
+
+		// Assert
+		s.Require().Equal(webhook.URL, createdWebhook.URL)
+		s.Require().Equal(existingUser.UserID, *createdWebhook.UserID)
+		s.Require().NotEmpty(createdWebhook.Secret)
+	})
+
+	deliveriesPath := webhookPath + "/" + uuid.UUID(createdWebhook.ID).String() + "/deliveries"
+
+	s.Run("deliveries for subscribed events only", func() {
+		// the deposit is consumed twice, and enqueued once
+		for i, eventType := range []string{models.EventDeposit, models.EventDeposit, models.EventWithdraw} {
+			err = s.db.EnqueueWebhookDeliveries(ctx, models.WebhookEvent{
+				Key:       "transaction_updates/0/" + strconv.Itoa(i/2),
+				Type:      eventType,
+				WalletIDs: []models.WalletID{createdWallet.WalletID},
+				Payload:   json.RawMessage(`{"money":100}`),
+			})
+			s.Require().NoError(err)
+		}
+
+		var deliveries []models.WebhookDelivery
+
+		// Act
+		s.sendRequest(http.MethodGet, deliveriesPath, http.StatusOK, nil, &deliveries, existingUser)
+
+		// Assert
+		s.Require().Len(deliveries, 1)
+		s.Require().Equal(models.EventDeposit, deliveries[0].EventType)
+		s.Require().Equal(models.DeliveryPending, deliveries[0].Status)
+
+		redeliverPath := deliveriesPath + "/" + uuid.UUID(deliveries[0].ID).String() + "/redeliver"
+
+		s.sendRequest(http.MethodPost, redeliverPath, http.StatusAccepted, nil, nil, existingUser)
+	})
+
+	s.Run("user is not the owner of the webhook", func() {
+		userFromAnotherMother := models.User{
+			UserID: models.UserID(uuid.New()),
+		}
+
+		// Act
+		s.sendRequest(http.MethodGet, deliveriesPath, http.StatusOK, nil, nil, userFromAnotherMother)
+		s.sendRequest(http.MethodDelete, webhookPath+"/"+uuid.UUID(createdWebhook.ID).String(),
+			http.StatusNotFound, nil, nil, userFromAnotherMother)
+	})
+
+	s.Run("deleted successfully", func() {
+		var webhooks []models.Webhook
+
+		// Act
+		s.sendRequest(http.MethodDelete, webhookPath+"/"+uuid.UUID(createdWebhook.ID).String(),
+			http.StatusOK, nil, nil, existingUser)
+
+		// Assert
+		s.sendRequest(http.MethodGet, webhookPath, http.StatusOK, nil, &webhooks, existingUser)
+		s.Require().Empty(webhooks)
+	})
+
+	s.Run("deliveries of a deleted webhook are not sent", func() {
+		// Act
+		deliveries, err := s.db.ClaimWebhookDeliveries(ctx, 100, time.Minute)
+
+		// Assert
+		s.Require().NoError(err)
+
+		for _, delivery := range deliveries {
+			s.Require().NotEqual(createdWebhook.ID, delivery.WebhookID)
+		}
+	})
+}
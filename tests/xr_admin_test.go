@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	jwtclaims "github.com/Memonagi/wallet_project/internal/jwt-claims"
+	"github.com/Memonagi/wallet_project/internal/models"
+	"github.com/google/uuid"
+)
+
+const ratesAdminPath = "/api/v1/xr/admin/rates"
+
+func (s *IntegrationTestSuite) TestRateOverrides() {
+	adminID := models.UserID(uuid.New())
+	admin := "Bearer " + s.signToken(jwtclaims.Claims{UserID: adminID, Role: models.RoleAdmin})
+	user := "Bearer " + s.getToken(existingUser)
+
+	override := models.RateOverride{From: "RUB", To: "CAD", Bid: 0.9, Ask: 1.1}
+
+	s.Run("token required", func() {
+		s.sendXRRequest(http.MethodPost, ratesAdminPath, http.StatusUnauthorized, &override, nil, "")
+	})
+
+	s.Run("admin required", func() {
+		s.sendXRRequest(http.MethodPost, ratesAdminPath, http.StatusForbidden, &override, nil, user)
+	})
+
+	s.Run("admin scope required", func() {
+		scoped := "Bearer " + s.signToken(jwtclaims.Claims{
+			UserID: adminID,
+			Role:   models.RoleAdmin,
+			Scope:  models.ScopeWalletsRead,
+		})
+
+		s.sendXRRequest(http.MethodPost, ratesAdminPath, http.StatusForbidden, &override, nil, scoped)
+	})
+
+	s.Run("revoked admin token", func() {
+		token := s.signToken(jwtclaims.Claims{UserID: adminID, Role: models.RoleAdmin})
+
+		s.sendRequestWithHeader(http.MethodPost, tokensPath+"/revoke", http.StatusOK,
+			&models.TokenRevocation{Token: token}, nil, "Authorization", "Bearer "+token)
+
+		s.sendXRRequest(http.MethodPost, ratesAdminPath, http.StatusUnauthorized, &override, nil, "Bearer "+token)
+	})
+
+	s.Run("invalid rate", func() {
+		invalid := models.RateOverride{From: "RUB", To: "CAD", Bid: 2, Ask: 1}
+
+		s.sendXRRequest(http.MethodPost, ratesAdminPath, http.StatusBadRequest, &invalid, nil, admin)
+	})
+
+	var created models.RateOverride
+
+	s.sendXRRequest(http.MethodPost, ratesAdminPath, http.StatusCreated, &override, &created, admin)
+
+	s.Run("rate applies without a restart", func() {
+		var response models.XRResponse
+
+		s.sendXRRequest(http.MethodGet, "/api/v1/xr?from=RUB&to=CAD", http.StatusOK, nil, &response,
+			"Bearer "+xrToken)
+
+		s.Require().InDelta(1.0, response.Rate, 1e-9)
+		s.Require().InDelta(0.9, response.Bid, 1e-9)
+		s.Require().InDelta(1.1, response.Ask, 1e-9)
+	})
+
+	s.Run("overrides are listed", func() {
+		var overrides []models.RateOverride
+
+		s.sendXRRequest(http.MethodGet, ratesAdminPath, http.StatusOK, nil, &overrides, admin)
+
+		s.Require().Contains(overrides, created)
+	})
+
+	expirePath := ratesAdminPath + "/" + uuid.UUID(created.ID).String() + "/expire"
+
+	s.Run("expire", func() {
+		var expired models.RateOverride
+
+		s.sendXRRequest(http.MethodPost, expirePath, http.StatusOK, nil, &expired, admin)
+
+		s.Require().NotNil(expired.ExpiresAt)
+	})
+
+	s.Run("unknown override", func() {
+		path := ratesAdminPath + "/" + uuid.NewString() + "/expire"
+
+		s.sendXRRequest(http.MethodPost, path, http.StatusNotFound, nil, nil, admin)
+	})
+
+	s.Run("history of changes", func() {
+		var changes []models.RateChange
+
+		s.sendXRRequest(http.MethodGet, ratesAdminPath+"/history?from=RUB&to=CAD", http.StatusOK, nil, &changes,
+			admin)
+
+		s.Require().Len(changes, 2)
+		s.Require().Equal(models.RateChangeSet, changes[0].Action)
+		s.Require().Equal(models.RateChangeExpire, changes[1].Action)
+		s.Require().Equal(uuid.UUID(adminID).String(), changes[1].Actor)
+	})
+}
+
+func (s *IntegrationTestSuite) sendXRRequest(method, path string, status int, entity, result any,
+	authorization string,
+) {
+	var body io.Reader = http.NoBody
+
+	if entity != nil {
+		data, err := json.Marshal(entity)
+		s.Require().NoError(err)
+
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), method, xrAddress+path, body)
+	s.Require().NoError(err)
+
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+
+	defer func() {
+		err = resp.Body.Close()
+		s.Require().NoError(err)
+	}()
+
+	s.Require().Equal(status, resp.StatusCode)
+
+	if result == nil {
+		return
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(result)
+	s.Require().NoError(err)
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Memonagi/wallet_project/internal/models"
+)
+
+func (s *IntegrationTestSuite) TestXRServerAuthentication() {
+	const ratePath = "/api/v1/xr?from=RUB&to=USD"
+
+	s.Run("token required", func() {
+		s.sendXRRequest(http.MethodGet, ratePath, http.StatusUnauthorized, nil, nil, "")
+	})
+
+	s.Run("wrong token", func() {
+		s.sendXRRequest(http.MethodGet, ratePath, http.StatusUnauthorized, nil, nil, "Bearer wrong")
+	})
+
+	s.Run("user token is not a service token", func() {
+		s.sendXRRequest(http.MethodGet, ratePath, http.StatusUnauthorized, nil, nil,
+			"Bearer "+s.getToken(existingUser))
+	})
+
+	s.Run("service token", func() {
+		var response models.XRResponse
+
+		s.sendXRRequest(http.MethodGet, ratePath, http.StatusOK, nil, &response, "Bearer "+xrToken)
+
+		s.Require().InDelta(1.5, response.Rate, 1e-9)
+	})
+
+	s.Run("client sends the token", func() {
+		rate, err := s.client.GetRate(context.Background(), "RUB", "EUR")
+		s.Require().NoError(err)
+		s.Require().InDelta(1.6, rate, 1e-9)
+	})
+}
+
+func (s *IntegrationTestSuite) TestXRServerProbes() {
+	s.Run("health without a token", func() {
+		s.sendXRRequest(http.MethodGet, "/healthz", http.StatusOK, nil, nil, "")
+	})
+
+	s.Run("metrics of the requests", func() {
+		s.sendXRRequest(http.MethodGet, "/api/v1/xr?from=RUB&to=USD", http.StatusOK, nil, nil, "Bearer "+xrToken)
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, xrAddress+"/metrics", nil)
+		s.Require().NoError(err)
+
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+
+		defer func() {
+			err = resp.Body.Close()
+			s.Require().NoError(err)
+		}()
+
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		body, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Require().Contains(string(body), `xr_service_server_http_request_total{endpoint="GET/api/v1/xr",status="200"}`)
+	})
+}